@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/sheet"
+)
+
+// applyApproval restricts issues to the keys kept in an approved preview
+// spreadsheet. Every approved key must have been in the original preview,
+// as recorded in its manifest, and still be part of the selection, so rows
+// can be removed from the sheet but not added.
+func applyApproval(issues []jira.Issue, path string) ([]jira.Issue, error) {
+	previewed, err := readPreviewManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := sheet.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	keyColumn := -1
+	for i, name := range rows[0] {
+		if strings.EqualFold(strings.TrimSpace(name), previewHeader[0]) {
+			keyColumn = i
+			break
+		}
+	}
+	if keyColumn < 0 {
+		return nil, fmt.Errorf("%s has no %q column", path, previewHeader[0])
+	}
+
+	var entries []string
+	for _, row := range rows[1:] {
+		if keyColumn < len(row) && strings.TrimSpace(row[keyColumn]) != "" {
+			entries = append(entries, row[keyColumn])
+		}
+	}
+	keys, invalid := jira.NormalizeKeys(entries)
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%s has %d invalid issue keys: %s", path, len(invalid), strings.Join(invalid, ", "))
+	}
+
+	var added []string
+	approved := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		approved[key] = true
+		if !previewed[key] {
+			added = append(added, key)
+		}
+	}
+	if len(added) > 0 {
+		jira.SortKeys(added)
+		return nil, fmt.Errorf("%d approved issues were not in the original preview: %s", len(added), strings.Join(added, ", "))
+	}
+
+	var selected []jira.Issue
+	matched := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		matched[issue.Key] = true
+		if approved[issue.Key] {
+			selected = append(selected, issue)
+		}
+	}
+
+	var unknown []string
+	for key := range approved {
+		if !matched[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		jira.SortKeys(unknown)
+		return nil, fmt.Errorf("%d approved issues are not in the current selection: %s", len(unknown), strings.Join(unknown, ", "))
+	}
+
+	return selected, nil
+}
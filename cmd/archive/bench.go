@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/fakejira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/selector"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+const (
+	benchProject = "BENCH"
+	benchLabel   = "archive"
+)
+
+// runBench runs the search and archive pipeline against the built-in fake
+// Jira for every combination of dataset size and batch size, reporting
+// throughput. It needs no Jira credentials and never contacts Jira.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sizes := fs.String("issues", "1000,10000", "comma-separated dataset sizes")
+	batchSizes := fs.String("batch-sizes", "100,500,1000", "comma-separated batch sizes (at most 1000)")
+	latency := fs.Duration("latency", 20*time.Millisecond, "simulated round-trip time per request")
+	fs.Parse(args)
+
+	issueCounts, err := parseIntList(*sizes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --issues: %v\n", err)
+		return 2
+	}
+	batches, err := parseIntList(*batchSizes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --batch-sizes: %v\n", err)
+		return 2
+	}
+	for _, size := range batches {
+		if size > 1000 {
+			fmt.Fprintf(os.Stderr, "Invalid --batch-sizes: %d exceeds the API limit of 1000\n", size)
+			return 2
+		}
+	}
+
+	// The pipeline logs every request and issue; keep the report readable
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Issues\tBatch size\tRequests\tSearch\tArchive\tIssues/s\t")
+	for _, count := range issueCounts {
+		for _, batchSize := range batches {
+			result, err := benchOnce(count, batchSize, *latency)
+			if err != nil {
+				w.Flush()
+				fmt.Fprintf(os.Stderr, "Benchmark with %d issues, batch size %d failed: %v\n", count, batchSize, err)
+				return exitFailures
+			}
+			fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%.0f\t\n", count, batchSize, result.requests,
+				result.search.Round(time.Millisecond), result.archive.Round(time.Millisecond),
+				float64(count)/(result.search+result.archive).Seconds())
+		}
+	}
+	w.Flush()
+	return exitOK
+}
+
+type benchResult struct {
+	requests int
+	search   time.Duration
+	archive  time.Duration
+}
+
+// benchOnce archives count synthetic issues in batches of batchSize
+func benchOnce(count, batchSize int, latency time.Duration) (benchResult, error) {
+	server := fakejira.New(fakejira.Generate(count, benchProject, benchLabel), latency)
+	defer server.Close()
+
+	client := jira.NewClient(server.URL, "bench@example.com", "bench")
+
+	start := time.Now()
+	issues, err := selector.Label(client, benchProject, benchLabel).Issues()
+	if err != nil {
+		return benchResult{}, err
+	}
+	searched := time.Now()
+
+	archiver := worker.NewArchiver(client, 0)
+	archiver.SetBatchSize(batchSize)
+	results, err := archiver.ArchiveIssues(issues)
+	if err != nil {
+		return benchResult{}, err
+	}
+	finished := time.Now()
+
+	for _, result := range results {
+		if !result.Success {
+			return benchResult{}, fmt.Errorf("%s: %v", result.IssueKey, result.Error)
+		}
+	}
+	if archived := server.Archived(); archived != count {
+		return benchResult{}, fmt.Errorf("archived %d of %d issues", archived, count)
+	}
+
+	return benchResult{
+		requests: server.Requests(),
+		search:   searched.Sub(start),
+		archive:  finished.Sub(searched),
+	}, nil
+}
+
+// parseIntList parses a comma-separated list of positive integers
+func parseIntList(value string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q is not a positive integer", field)
+		}
+		values = append(values, n)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values given")
+	}
+	return values, nil
+}
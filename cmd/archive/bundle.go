@@ -0,0 +1,229 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// supportLogBytes is how much of the end of the log a support bundle keeps
+const supportLogBytes = 1 << 20
+
+// supportBodyBytes bounds the response body kept per failed request
+const supportBodyBytes = 4096
+
+// redacted replaces secrets in support bundles
+const redacted = "[redacted]"
+
+// secretFields are the Config fields left out of support bundles. The
+// freeze calendar and warehouse URLs are included because private iCal
+// links and signed bucket URLs embed a token.
+var secretFields = []string{
+	"JiraAPIToken",
+	"SentryDSN",
+	"PagerDutyRoutingKey",
+	"OpsgenieAPIKey",
+	"FreezeCalendarURL",
+	"WarehouseURL",
+	"WarehouseToken",
+}
+
+// failedRequest is the metadata of a Jira API response that failed the run
+// or some of its issues
+type failedRequest struct {
+	Issues     []string    `json:"issues,omitempty"`
+	StatusCode int         `json:"statusCode"`
+	RequestID  string      `json:"requestId,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// writeSupportBundle writes a zip with the sanitized configuration, the end
+// of the log, the failed requests, the run result and an environment
+// summary to SUPPORT_BUNDLE_DIR. Errors are logged; they never change the
+// outcome of the run.
+func writeSupportBundle(cfg *config.Config, logs *logging.Tail, result *worker.RunResult, runErr error) {
+	if cfg.SupportBundleDir == "" {
+		return
+	}
+	now := time.Now()
+	name := "support-" + now.Format("20060102-150405")
+	if result != nil && result.RunID != "" {
+		name = "support-" + result.RunID
+	}
+	path := filepath.Join(cfg.SupportBundleDir, name+".zip")
+
+	if err := os.MkdirAll(cfg.SupportBundleDir, 0o700); err != nil {
+		logger.Warnf("Failed to write support bundle: %v", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		logger.Warnf("Failed to write support bundle: %v", err)
+		return
+	}
+	// Read the log before the bundle's own messages are added to it
+	var logText string
+	if logs != nil {
+		logText = redactSecrets(cfg, string(logs.Bytes()))
+	}
+
+	zw := zip.NewWriter(f)
+	err = errors.Join(
+		addBundleJSON(zw, "config.json", sanitizedConfig(cfg)),
+		addBundleFile(zw, "log.txt", logText),
+		addBundleFile(zw, "error.txt", bundleError(cfg, runErr)),
+		addBundleJSON(zw, "requests.json", failedRequests(result, runErr)),
+		addBundleFile(zw, "environment.txt", environmentSummary(now)),
+	)
+	if result != nil {
+		err = errors.Join(err, addBundleJSON(zw, "result.json", result))
+	}
+	err = errors.Join(err, zw.Close(), f.Close())
+	if err != nil {
+		logger.Warnf("Failed to write support bundle %s: %v", path, err)
+		return
+	}
+	logger.Infof("Wrote support bundle %s. Review it before attaching it to a bug report.", path)
+}
+
+func addBundleFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(content))
+	return err
+}
+
+func addBundleJSON(zw *zip.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return addBundleFile(zw, name, string(data)+"\n")
+}
+
+// sanitizedConfig returns the effective configuration with the secrets
+// replaced and the email reduced to its domain
+func sanitizedConfig(cfg *config.Config) map[string]any {
+	data, _ := json.Marshal(cfg)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	for _, name := range secretFields {
+		if s, _ := fields[name].(string); s != "" {
+			fields[name] = redacted
+		}
+	}
+	if _, domain, ok := strings.Cut(cfg.JiraEmail, "@"); ok {
+		fields["JiraEmail"] = "***@" + domain
+	}
+	return fields
+}
+
+// redactSecrets replaces any configured secret appearing in text
+func redactSecrets(cfg *config.Config, text string) string {
+	secrets := []string{
+		cfg.JiraAPIToken,
+		cfg.SentryDSN,
+		cfg.PagerDutyRoutingKey,
+		cfg.OpsgenieAPIKey,
+		cfg.FreezeCalendarURL,
+		cfg.WarehouseURL,
+		cfg.WarehouseToken,
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return text
+}
+
+func bundleError(cfg *config.Config, runErr error) string {
+	if runErr == nil {
+		return "The run finished with failed issues; see result.json.\n"
+	}
+	return redactSecrets(cfg, runErr.Error()) + "\n"
+}
+
+// failedRequests collects the API errors behind the run error and the
+// failed issues. Issues from one failed batch share a single entry.
+func failedRequests(result *worker.RunResult, runErr error) []*failedRequest {
+	requests := []*failedRequest{}
+	seen := map[*jira.APIError]*failedRequest{}
+	add := func(err error, issue string) {
+		var apiErr *jira.APIError
+		if !errors.As(err, &apiErr) {
+			return
+		}
+		req, ok := seen[apiErr]
+		if !ok {
+			body := apiErr.Body
+			if len(body) > supportBodyBytes {
+				body = body[:supportBodyBytes] + "..."
+			}
+			req = &failedRequest{
+				StatusCode: apiErr.StatusCode,
+				RequestID:  apiErr.RequestID(),
+				Header:     apiErr.Header,
+				Body:       body,
+			}
+			seen[apiErr] = req
+			requests = append(requests, req)
+		}
+		if issue != "" {
+			req.Issues = append(req.Issues, issue)
+		}
+	}
+
+	add(runErr, "")
+	if result != nil {
+		for _, r := range result.Results {
+			if r.Error != nil && !r.Skipped {
+				add(r.Error, r.IssueKey)
+			}
+		}
+	}
+	return requests
+}
+
+// environmentSummary describes the build and host without identifying it
+func environmentSummary(now time.Time) string {
+	var b strings.Builder
+	version, revision := "unknown", ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				revision = s.Value
+			}
+		}
+	}
+	fmt.Fprintf(&b, "Version: %s\n", version)
+	if revision != "" {
+		fmt.Fprintf(&b, "Revision: %s\n", revision)
+	}
+	fmt.Fprintf(&b, "Go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Time: %s\n", now.Format(time.RFC3339))
+	proxy := "no"
+	if os.Getenv("HTTPS_PROXY") != "" || os.Getenv("https_proxy") != "" {
+		proxy = "yes"
+	}
+	fmt.Fprintf(&b, "Proxy configured: %s\n", proxy)
+	return b.String()
+}
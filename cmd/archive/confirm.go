@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// errNotConfirmed ends a run whose archive was declined at the prompt
+var errNotConfirmed = errors.New("archive not confirmed")
+
+// confirmArchive lists the first list matched issues and asks for the
+// archive to be confirmed by typing yes or the project key. A mis-set label
+// can match thousands of issues, so a plain y is not enough. Without a
+// terminal on stdin, as under cron or CI, there is nobody to ask and the
+// issues are returned unchanged.
+func confirmArchive(projectKey string, issues []jira.Issue, list int) ([]jira.Issue, error) {
+	if !stdinIsTerminal() {
+		return issues, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d issues will be archived", len(issues))
+	if list > 0 {
+		fmt.Fprintln(os.Stderr, ":")
+		fmt.Fprintln(os.Stderr)
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		for _, issue := range issues[:min(list, len(issues))] {
+			fmt.Fprintf(w, "  %s\t%s\n", issue.Key, issue.Fields.Summary)
+		}
+		w.Flush()
+		if more := len(issues) - list; more > 0 {
+			fmt.Fprintf(os.Stderr, "  ... and %d more\n", more)
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, ".")
+	}
+
+	if projectKey != "" {
+		fmt.Fprintf(os.Stderr, "\nType yes or %s to archive them: ", projectKey)
+	} else {
+		fmt.Fprint(os.Stderr, "\nType yes to archive them: ")
+	}
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if strings.EqualFold(answer, "yes") || (projectKey != "" && strings.EqualFold(answer, projectKey)) {
+		return issues, nil
+	}
+	return nil, errNotConfirmed
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
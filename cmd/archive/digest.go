@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/messages"
+)
+
+// runDigest prints one summary of the last days of runs per project, meant
+// to replace reading every nightly run's output
+func runDigest(args []string) int {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	days := fs.Int("days", 7, "number of days to aggregate")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Parse(args)
+
+	if *days < 1 {
+		fmt.Fprintln(os.Stderr, "--days must be at least 1")
+		return 2
+	}
+
+	cfg := loadConfig()
+	if cfg.HistoryFile == "" {
+		logger.Fatalf("HISTORY_FILE is required for the digest")
+	}
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		logger.Fatalf("Failed to read run history: %v", err)
+	}
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -*days)
+	digests := history.Digest(runs, since)
+
+	switch *format {
+	case "text":
+		printMessage(os.Stdout, messages.New(cfg.TemplateDir, cfg.Locale), messages.Digest, map[string]any{
+			"Since":    since.Format("2006-01-02"),
+			"Until":    until.Format("2006-01-02"),
+			"Projects": digests,
+		})
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]any{
+			"since":    since.UTC().Format(time.RFC3339),
+			"until":    until.UTC().Format(time.RFC3339),
+			"projects": digests,
+		}); err != nil {
+			logger.Fatalf("Failed to write digest: %v", err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", *format)
+		return 2
+	}
+	return exitOK
+}
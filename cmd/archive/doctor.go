@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+// doctorTimeout bounds each network check
+const doctorTimeout = 10 * time.Second
+
+// doctorCheck is one diagnostic layer. run returns a short description of
+// what it found; a skipped layer returns a description and errSkipped.
+type doctorCheck struct {
+	layer string
+	run   func() (string, error)
+}
+
+var errSkipped = errors.New("skipped")
+
+// runDoctor checks the connection to Jira layer by layer, from the URL
+// through DNS, TLS, proxy, authentication and permissions to the API
+// endpoints, and stops at the first layer that fails
+func runDoctor(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s doctor\n", os.Args[0])
+		return 2
+	}
+	cfg := loadConfig()
+
+	d := &doctor{cfg: cfg}
+	checks := []doctorCheck{
+		{"url", d.checkURL},
+		{"proxy", d.checkProxy},
+		{"dns", d.checkDNS},
+		{"tcp", d.checkTCP},
+		{"tls", d.checkTLS},
+		{"http", d.checkHTTP},
+		{"auth", d.checkAuth},
+		{"permission", d.checkPermission},
+		{"endpoints", d.checkEndpoints},
+		{"fields", d.checkFields},
+	}
+
+	fmt.Printf("Checking %s\n\n", cfg.JiraBaseURL)
+	for _, check := range checks {
+		detail, err := check.run()
+		switch {
+		case errors.Is(err, errSkipped):
+			fmt.Printf("  [SKIP] %-10s %s\n", check.layer, detail)
+		case err != nil:
+			fmt.Printf("  [FAIL] %-10s %v\n", check.layer, err)
+			fmt.Printf("\nThe %s layer failed; the layers after it were not checked.\n", check.layer)
+			return exitFailures
+		default:
+			fmt.Printf("  [OK  ] %-10s %s\n", check.layer, detail)
+		}
+	}
+	fmt.Println("\nAll checks passed.")
+	return exitOK
+}
+
+// doctor holds what earlier checks found for the later ones
+type doctor struct {
+	cfg   *config.Config
+	base  *url.URL
+	addr  string
+	proxy *url.URL
+	// user is the display name the credentials authenticated as
+	user string
+}
+
+// account names the user the checks run as in messages
+func (d *doctor) account() string {
+	if d.cfg.JiraAuthMethod == jira.AuthBasic {
+		return d.cfg.JiraEmail
+	}
+	return d.user
+}
+
+func (d *doctor) checkURL() (string, error) {
+	u, err := url.Parse(d.cfg.JiraBaseURL)
+	if err != nil {
+		return "", fmt.Errorf("JIRA_BASE_URL is not a URL: %v", err)
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return "", fmt.Errorf("JIRA_BASE_URL must be an https URL such as https://your-domain.atlassian.net, not %q", d.cfg.JiraBaseURL)
+	}
+	d.base = u
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	d.addr = net.JoinHostPort(u.Hostname(), port)
+	return u.Hostname(), nil
+}
+
+func (d *doctor) checkProxy() (string, error) {
+	// The client uses the default transport, which honors HTTPS_PROXY and NO_PROXY
+	proxy, err := http.ProxyFromEnvironment(&http.Request{URL: d.base})
+	if err != nil {
+		return "", fmt.Errorf("invalid proxy setting: %v", err)
+	}
+	if proxy == nil {
+		return "none (direct connection)", nil
+	}
+	d.proxy = proxy
+
+	addr := proxy.Host
+	if proxy.Port() == "" {
+		addr = net.JoinHostPort(proxy.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", addr, doctorTimeout)
+	if err != nil {
+		return "", fmt.Errorf("cannot connect to proxy %s: %v (check HTTPS_PROXY)", proxy.Redacted(), err)
+	}
+	conn.Close()
+	return fmt.Sprintf("%s is reachable", proxy.Redacted()), nil
+}
+
+func (d *doctor) checkDNS() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, d.base.Hostname())
+	if err != nil {
+		if d.proxy != nil {
+			return "not resolvable locally; left to the proxy", errSkipped
+		}
+		return "", fmt.Errorf("cannot resolve %s: %v (check the domain in JIRA_BASE_URL and the DNS settings)", d.base.Hostname(), err)
+	}
+	return fmt.Sprintf("%s resolves to %s", d.base.Hostname(), addrs[0]), nil
+}
+
+func (d *doctor) checkTCP() (string, error) {
+	if d.proxy != nil {
+		return "connections go through the proxy", errSkipped
+	}
+	conn, err := net.DialTimeout("tcp", d.addr, doctorTimeout)
+	if err != nil {
+		return "", fmt.Errorf("cannot connect to %s: %v (a firewall may block outbound HTTPS; set HTTPS_PROXY if a proxy is required)", d.addr, err)
+	}
+	conn.Close()
+	return fmt.Sprintf("connected to %s", d.addr), nil
+}
+
+func (d *doctor) checkTLS() (string, error) {
+	if d.proxy != nil {
+		return "checked through the proxy by the http layer", errSkipped
+	}
+	dialer := &net.Dialer{Timeout: doctorTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", d.addr, &tls.Config{ServerName: d.base.Hostname()})
+	if err != nil {
+		return "", fmt.Errorf("TLS handshake with %s failed: %v (an intercepting proxy or missing CA certificate is a common cause)", d.addr, err)
+	}
+	defer conn.Close()
+	cert := conn.ConnectionState().PeerCertificates[0]
+	return fmt.Sprintf("certificate for %s issued by %s, valid until %s",
+		cert.Subject.CommonName, cert.Issuer.CommonName, cert.NotAfter.Format("2006-01-02")), nil
+}
+
+func (d *doctor) checkHTTP() (string, error) {
+	if d.cfg.JiraAuthMethod == jira.AuthOAuth {
+		return "the OAuth gateway does not serve /status", errSkipped
+	}
+	resp, err := d.get("/status", false)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return "", fmt.Errorf("the proxy requires authentication (407); add credentials to HTTPS_PROXY")
+	}
+	var status struct {
+		State string `json:"state"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&status) != nil {
+		return "", fmt.Errorf("%s/status returned %s; this does not look like a Jira Cloud site", d.cfg.JiraBaseURL, resp.Status)
+	}
+	if status.State != "RUNNING" {
+		return "", fmt.Errorf("the site reports state %s", status.State)
+	}
+	return "site is running", nil
+}
+
+func (d *doctor) checkAuth() (string, error) {
+	resp, err := d.get("/rest/api/3/myself", true)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		if d.cfg.JiraAuthMethod != jira.AuthBasic {
+			return "", fmt.Errorf("the %s token was rejected (401); it may have expired or been revoked", d.cfg.JiraAuthMethod)
+		}
+		return "", fmt.Errorf("%s and the API token were rejected (401); check JIRA_EMAIL and create a new token if it was revoked or expired", d.cfg.JiraEmail)
+	case http.StatusForbidden:
+		if d.cfg.JiraAuthMethod == jira.AuthOAuth {
+			return "", fmt.Errorf("the access token was refused (403); the OAuth app needs the read:jira-user, read:jira-work and write:jira-work scopes")
+		}
+		if reason := resp.Header.Get("X-Seraph-LoginReason"); reason != "" {
+			return "", fmt.Errorf("login refused (403, %s); sign in through the browser once to clear a CAPTCHA", reason)
+		}
+		return "", fmt.Errorf("the account is not allowed to use the REST API (403)")
+	default:
+		return "", fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	var user jira.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("failed to decode response: %v", err)
+	}
+	d.user = user.DisplayName
+	return fmt.Sprintf("authenticated as %s", user.DisplayName), nil
+}
+
+func (d *doctor) checkPermission() (string, error) {
+	client := jira.NewClient(d.cfg.JiraBaseURL, d.cfg.JiraEmail, d.cfg.JiraAPIToken)
+	client.SetAuthMethod(d.cfg.JiraAuthMethod)
+	projects := d.cfg.ProjectKeys()
+	if len(projects) == 0 {
+		return "JIRA_PROJECT_KEY is not set", errSkipped
+	}
+	for _, project := range projects {
+		granted, err := client.GetMyPermissions(project, jira.PermissionBrowseProjects, jira.PermissionAdminister, jira.PermissionAdministerProjects)
+		if err != nil {
+			return "", fmt.Errorf("permission check for project %s failed: %v", project, err)
+		}
+		if !granted[jira.PermissionBrowseProjects] {
+			return "", fmt.Errorf("project %s does not exist or is not visible to %s", project, d.account())
+		}
+		if !granted[jira.PermissionAdminister] && !granted[jira.PermissionAdministerProjects] {
+			return "", fmt.Errorf("%s cannot archive issues in %s (Jira or project administrator permission required)", d.account(), project)
+		}
+	}
+	return fmt.Sprintf("may archive issues in %s", strings.Join(projects, ", ")), nil
+}
+
+func (d *doctor) checkEndpoints() (string, error) {
+	client := jira.NewClient(d.cfg.JiraBaseURL, d.cfg.JiraEmail, d.cfg.JiraAPIToken)
+	client.SetAuthMethod(d.cfg.JiraAuthMethod)
+	project := ""
+	if projects := d.cfg.ProjectKeys(); len(projects) > 0 {
+		project = projects[0]
+	}
+	jql := d.cfg.SearchJQL(project)
+	if _, err := client.SearchIssues(jql, "", 1); err != nil {
+		return "", fmt.Errorf("issue search failed: %v", err)
+	}
+	// Probing the archive endpoint would archive issues; it needs Jira Premium or Enterprise
+	return "issue search works (the archive endpoint is not probed)", nil
+}
+
+func (d *doctor) checkFields() (string, error) {
+	if len(d.cfg.SearchFields) == 0 {
+		return "SEARCH_FIELDS is not set", errSkipped
+	}
+	client := jira.NewClient(d.cfg.JiraBaseURL, d.cfg.JiraEmail, d.cfg.JiraAPIToken)
+	client.SetAuthMethod(d.cfg.JiraAuthMethod)
+	resolved := make([]string, len(d.cfg.SearchFields))
+	for i, f := range d.cfg.SearchFields {
+		id, err := client.FieldID(f)
+		if err != nil {
+			return "", fmt.Errorf("SEARCH_FIELDS: %v", err)
+		}
+		resolved[i] = id
+		if id != f {
+			resolved[i] = fmt.Sprintf("%s (%s)", id, f)
+		}
+	}
+	return "SEARCH_FIELDS are " + strings.Join(resolved, ", "), nil
+}
+
+// get sends a GET request to the site the way the client does
+func (d *doctor) get(path string, auth bool) (*http.Response, error) {
+	req, err := http.NewRequest("GET", d.base.JoinPath(path).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth {
+		jira.Authorize(req, d.cfg.JiraAuthMethod, d.cfg.JiraEmail, d.cfg.JiraAPIToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := transport.Client(doctorTimeout)
+	return client.Do(req)
+}
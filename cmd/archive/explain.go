@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// runExplain evaluates the configured criteria against a single issue and
+// prints why it would or wouldn't be archived
+func runExplain(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s explain ISSUE-KEY\n", os.Args[0])
+		return 2
+	}
+	issueKey := strings.ToUpper(strings.TrimSpace(args[0]))
+	if !jira.ValidKey(issueKey) {
+		fmt.Fprintf(os.Stderr, "%q is not an issue key (expected e.g. PROJ-123)\n", args[0])
+		return 2
+	}
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	issue, err := client.GetIssue(issueKey, "summary,status,project,issuetype,labels,archiveddate,security,subtasks")
+	if err != nil {
+		logger.Fatalf("Failed to fetch %s: %v", issueKey, err)
+	}
+
+	projectKey := ""
+	if issue.Fields.Project != nil {
+		projectKey = issue.Fields.Project.Key
+	}
+	projects := cfg.ProjectKeys()
+	inProject := slices.ContainsFunc(projects, func(key string) bool { return strings.EqualFold(key, projectKey) })
+	jqlProject := ""
+	if len(projects) > 0 {
+		jqlProject = projects[0]
+	}
+	if inProject {
+		jqlProject = projectKey
+	}
+	jql := cfg.SearchJQL(jqlProject)
+	matched, err := client.MatchesJQL(issue.Key, jql)
+	if err != nil {
+		logger.Fatalf("Failed to evaluate JQL for %s: %v", issue.Key, err)
+	}
+
+	fmt.Printf("\n%s: %s\n\n", issue.Key, issue.Fields.Summary)
+
+	eligible := true
+	check := func(ok bool, description string) {
+		mark := "OK  "
+		if !ok {
+			mark = "FAIL"
+			eligible = false
+		}
+		fmt.Printf("  [%s] %s\n", mark, description)
+	}
+
+	check(issue.Fields.ArchivedDate == "", fmt.Sprintf("not already archived (archived date: %s)", valueOrNone(issue.Fields.ArchivedDate)))
+	if cfg.JQL == "" {
+		check(inProject, fmt.Sprintf("in project %s (issue project: %s)", strings.Join(projects, ", "), valueOrNone(projectKey)))
+		check(hasLabel(issue.Fields.Labels, cfg.ArchiveLabel), fmt.Sprintf("has label %q (labels: %s)", cfg.ArchiveLabel, valueOrNone(strings.Join(issue.Fields.Labels, ", "))))
+	}
+	check(matched, fmt.Sprintf("matched by JQL: %s", jql))
+	if cfg.HistoryFile != "" && cfg.SkipIneligibleDays > 0 {
+		runs, err := history.Open(cfg.HistoryFile).Runs()
+		if err != nil {
+			logger.Fatalf("Failed to read run history: %v", err)
+		}
+		reason, skipped := history.Ineligible(runs, time.Now().AddDate(0, 0, -cfg.SkipIneligibleDays))[issue.Key]
+		check(!skipped, fmt.Sprintf("not failed permanently in the last %d days (last error: %s)", cfg.SkipIneligibleDays, valueOrNone(reason)))
+	}
+
+	exclusions := worker.Exclusions{Labels: cfg.ExcludeLabels, Statuses: cfg.ExcludeStatuses, IssueTypes: cfg.ExcludeIssueTypes}
+	if cfg.ExcludeJQL() != "" {
+		reason := worker.ExclusionReason(*issue, exclusions)
+		check(reason == "", fmt.Sprintf("not excluded by EXCLUDE_LABELS, EXCLUDE_STATUSES or EXCLUDE_ISSUE_TYPES (%s)", valueOrNone(reason)))
+	}
+
+	if cfg.ArchiveSkipSecured || len(cfg.ArchiveSecurityLevels) > 0 {
+		level := ""
+		if issue.Fields.Security != nil {
+			level = issue.Fields.Security.Name
+		}
+		check(worker.SecurityReason(*issue, cfg.ArchiveSkipSecured, cfg.ArchiveSecurityLevels) == "",
+			fmt.Sprintf("security level allowed (level: %s)", valueOrNone(level)))
+	}
+
+	if worker.IsServiceDeskRequest(*issue) {
+		switch cfg.ServiceDeskPolicy {
+		case worker.ServiceDeskSkip:
+			check(false, "not a service desk request (SERVICE_DESK_POLICY=skip)")
+		case worker.ServiceDeskResolved:
+			status := ""
+			if issue.Fields.Status != nil {
+				status = issue.Fields.Status.Name
+			}
+			check(worker.ServiceDeskReason(*issue, cfg.ServiceDeskPolicy) == "",
+				fmt.Sprintf("service desk request resolved (status: %s)", valueOrNone(status)))
+		default:
+			fmt.Printf("  [NOTE] %s is a service desk request; it is archived even if the customer is still waiting\n", issue.Key)
+		}
+	}
+
+	// The archive API rejects subtasks with issueIsSubtask
+	check(issue.Fields.IssueType == nil || !issue.Fields.IssueType.Subtask,
+		"not a subtask (subtasks cannot be archived on their own; archive the parent instead)")
+
+	if open := openSubtasks(issue.Fields.Subtasks); len(open) > 0 {
+		fmt.Printf("  [NOTE] %d of %d subtasks are not done and would be archived with %s: %s\n",
+			len(open), len(issue.Fields.Subtasks), issue.Key, strings.Join(open, ", "))
+	}
+
+	if eligible {
+		fmt.Printf("\n%s would be archived.\n", issue.Key)
+	} else {
+		fmt.Printf("\n%s would NOT be archived.\n", issue.Key)
+	}
+	return exitOK
+}
+
+// openSubtasks returns the subtasks whose status is not in the done
+// category, as "KEY (status)"
+func openSubtasks(subtasks []jira.Issue) []string {
+	var open []string
+	for _, subtask := range subtasks {
+		status := subtask.Fields.Status
+		if status != nil && status.StatusCategory != nil && status.StatusCategory.Key == "done" {
+			continue
+		}
+		name := "unknown"
+		if status != nil {
+			name = status.Name
+		}
+		open = append(open, fmt.Sprintf("%s (%s)", subtask.Key, name))
+	}
+	return open
+}
+
+func hasLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/suggest"
+)
+
+// initAnswers holds the settings collected by the setup wizard
+type initAnswers struct {
+	baseURL    string
+	email      string
+	tokenFile  string // empty when the token comes from JIRA_API_TOKEN
+	projectKey string
+	label      string
+}
+
+// runInit asks for the essential settings, tests the connection and writes
+// them to an env file. The API token itself is never written: the file
+// refers to the JIRA_API_TOKEN variable or to a token file instead.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	path := fs.String("file", ".env", "env file to write")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s init [--file PATH] [--force]\n", os.Args[0])
+		return 2
+	}
+	if _, err := os.Stat(*path); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "%s already exists; use --force to overwrite it\n", *path)
+		return 2
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	answers, token, err := askInit(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nSetup cancelled: %v\n", err)
+		return exitFailures
+	}
+
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "\nSkipping the connection test: JIRA_API_TOKEN is not set in this shell.")
+	} else if err := testConnection(answers, token); err != nil {
+		fmt.Fprintf(os.Stderr, "\nConnection test failed: %v\n", err)
+		if !confirmFrom(in, "Write the configuration anyway?") {
+			return exitFailures
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, "\nConnection test passed.")
+	}
+
+	if err := os.WriteFile(*path, []byte(renderInit(answers, time.Now())), 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", *path, err)
+		return exitFailures
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s. See .env.example for optional settings.\n", *path)
+	if answers.tokenFile == "" {
+		fmt.Fprintln(os.Stderr, "Set JIRA_API_TOKEN in the environment before running the tool.")
+	}
+	return exitOK
+}
+
+// askInit prompts for each setting and returns the answers together with
+// the token to test the connection with (empty if none is available)
+func askInit(in *bufio.Reader) (initAnswers, string, error) {
+	var a initAnswers
+	var err error
+
+	fmt.Fprintln(os.Stderr, "This creates a configuration for the JIRA Cloud Bulk Archive Tool.")
+	fmt.Fprintln(os.Stderr, "Create an API token at https://id.atlassian.com/manage-profile/security/api-tokens")
+	fmt.Fprintln(os.Stderr)
+
+	if a.baseURL, err = ask(in, "Jira base URL (e.g. https://your-domain.atlassian.net)", "", validBaseURL); err != nil {
+		return a, "", err
+	}
+	a.baseURL = strings.TrimRight(a.baseURL, "/")
+	if a.email, err = ask(in, "Account email", "", required); err != nil {
+		return a, "", err
+	}
+
+	fmt.Fprintln(os.Stderr, "\nThe API token is not stored in the env file. Read it from:")
+	fmt.Fprintln(os.Stderr, "  1) the JIRA_API_TOKEN environment variable")
+	fmt.Fprintln(os.Stderr, "  2) a file, such as a mounted secret (JIRA_API_TOKEN_FILE)")
+	source, err := ask(in, "Token source", "1", oneOf("1", "2"))
+	if err != nil {
+		return a, "", err
+	}
+	var token string
+	if source == "2" {
+		if a.tokenFile, err = ask(in, "Token file path", "", required); err != nil {
+			return a, "", err
+		}
+		if token, err = config.ReadTokenFile(a.tokenFile); err != nil {
+			return a, "", err
+		}
+	} else {
+		token = os.Getenv("JIRA_API_TOKEN")
+	}
+
+	if a.projectKey, err = ask(in, "Project key", "", required); err != nil {
+		return a, "", err
+	}
+	a.projectKey = strings.ToUpper(a.projectKey)
+	if a.label, err = ask(in, "Archive label", "archive", required); err != nil {
+		return a, "", err
+	}
+	return a, token, nil
+}
+
+// ask prompts until check accepts the answer. An empty answer selects def.
+func ask(in *bufio.Reader, question, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: ", question)
+		}
+		line, err := in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if errors.Is(err, io.EOF) && answer == "" {
+			return "", errors.New("no more input")
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if problem := check(answer); problem != nil {
+			fmt.Fprintf(os.Stderr, "  %v\n", problem)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+func required(answer string) error {
+	if answer == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+func oneOf(choices ...string) func(string) error {
+	return func(answer string) error {
+		for _, c := range choices {
+			if answer == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("choose one of %s", strings.Join(choices, ", "))
+	}
+}
+
+func validBaseURL(answer string) error {
+	u, err := url.Parse(answer)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("enter an https URL such as https://your-domain.atlassian.net")
+	}
+	return nil
+}
+
+// confirmFrom asks a yes/no question on in, defaulting to no. Unlike
+// confirm it shares the wizard's buffered reader.
+func confirmFrom(in *bufio.Reader, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// testConnection checks the credentials, the project and the permission
+// needed to archive in it
+func testConnection(a initAnswers, token string) error {
+	client := jira.NewClient(a.baseURL, a.email, token)
+
+	projects, err := client.GetProjects()
+	if err != nil {
+		return fmt.Errorf("could not list projects (check the URL, email and token): %w", err)
+	}
+	var keys []string
+	found := false
+	for _, p := range projects {
+		keys = append(keys, p.Key)
+		found = found || p.Key == a.projectKey
+	}
+	if !found {
+		if matches := suggest.Closest(a.projectKey, keys, 1); len(matches) > 0 {
+			return fmt.Errorf("project %s not found; did you mean %s?", a.projectKey, matches[0])
+		}
+		return fmt.Errorf("project %s not found or not visible to %s", a.projectKey, a.email)
+	}
+
+	granted, err := client.GetMyPermissions(a.projectKey, jira.PermissionAdminister, jira.PermissionAdministerProjects)
+	if err != nil {
+		return fmt.Errorf("could not check permissions: %w", err)
+	}
+	if !granted[jira.PermissionAdminister] && !granted[jira.PermissionAdministerProjects] {
+		return fmt.Errorf("%s cannot archive issues in %s (Jira or project administrator permission required)", a.email, a.projectKey)
+	}
+	return nil
+}
+
+// renderInit formats the answers as an env file
+func renderInit(a initAnswers, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Written by init on %s. See .env.example for optional settings.\n\n", now.Format("2006-01-02"))
+	b.WriteString("# JIRA Cloud Configuration\n")
+	fmt.Fprintf(&b, "JIRA_BASE_URL=%s\n", a.baseURL)
+	fmt.Fprintf(&b, "JIRA_EMAIL=%s\n", a.email)
+	if a.tokenFile != "" {
+		fmt.Fprintf(&b, "JIRA_API_TOKEN_FILE=%s\n", a.tokenFile)
+	} else {
+		b.WriteString("# JIRA_API_TOKEN is read from the environment\n")
+	}
+	b.WriteString("\n# Project Configuration\n")
+	fmt.Fprintf(&b, "JIRA_PROJECT_KEY=%s\n", a.projectKey)
+	b.WriteString("\n# Archive Configuration\n")
+	fmt.Fprintf(&b, "ARCHIVE_LABEL=%s\n", a.label)
+	return b.String()
+}
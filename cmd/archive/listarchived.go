@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// runListArchived prints the keys of the issues archived by one run, one per
+// line, so they can be fed to a keys: selector or an unarchive
+func runListArchived(args []string) int {
+	fs := flag.NewFlagSet("list-archived", flag.ExitOnError)
+	runID := fs.String("run", "", "run ID to list")
+	fs.Parse(args)
+
+	if *runID == "" || fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s list-archived --run RUN-ID\n", os.Args[0])
+		return 2
+	}
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	run, keys, unconfirmed := runArchivedKeys(cfg, client, *runID)
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+
+	if cfg.RunPropertyKey == "" {
+		logger.Infof("Run %s archived %d issues (from local history; set RUN_PROPERTY_KEY to confirm against Jira)", run.ID, len(keys))
+		return exitOK
+	}
+	logger.Infof("Run %s: %d issues confirmed by %s, %d not confirmed", run.ID, len(keys), cfg.RunPropertyKey, unconfirmed)
+	if unconfirmed > 0 {
+		return exitFailures
+	}
+	return exitOK
+}
+
+// runArchivedKeys returns the keys of the issues archived by a run, sorted,
+// together with the number of candidates that could not be confirmed.
+//
+// Jira's search never returns archived issues, so the candidates come from
+// the local run history. When RUN_PROPERTY_KEY is set, each candidate is
+// confirmed against the run property stored on the issue itself.
+func runArchivedKeys(cfg *config.Config, client *jira.Client, runID string) (history.Run, []string, int) {
+	if cfg.HistoryFile == "" {
+		logger.Fatalf("HISTORY_FILE is required to find the issues of a run")
+	}
+
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		logger.Fatalf("Failed to read run history: %v", err)
+	}
+	run, ok := history.FindRun(runs, runID)
+	if !ok {
+		logger.Fatalf("Run %s not found in %s", runID, cfg.HistoryFile)
+	}
+
+	var candidates []string
+	for _, issue := range run.Issues {
+		if issue.Status == history.StatusArchived {
+			candidates = append(candidates, issue.Key)
+		}
+	}
+	jira.SortKeys(candidates)
+
+	if cfg.RunPropertyKey == "" {
+		return run, candidates, 0
+	}
+
+	var keys []string
+	unconfirmed := 0
+	for _, key := range candidates {
+		var property worker.RunProperty
+		found, err := client.GetIssueProperty(key, cfg.RunPropertyKey, &property)
+		switch {
+		case err != nil:
+			logger.Warnf("Failed to read %s on %s: %v", cfg.RunPropertyKey, key, err)
+			unconfirmed++
+		case !found:
+			logger.Infof("%s has no %s property", key, cfg.RunPropertyKey)
+			unconfirmed++
+		case property.RunID != run.ID:
+			logger.Infof("%s was last tagged by run %s", key, property.RunID)
+			unconfirmed++
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return run, keys, unconfirmed
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+)
+
+// runLookup prints who archived an issue and when
+func runLookup(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s lookup ISSUE-KEY\n", os.Args[0])
+		return 2
+	}
+	issueKey := strings.ToUpper(strings.TrimSpace(args[0]))
+	if !jira.ValidKey(issueKey) {
+		fmt.Fprintf(os.Stderr, "%q is not an issue key (expected e.g. PROJ-123)\n", args[0])
+		return 2
+	}
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	issue, err := client.GetIssue(issueKey, "summary,archiveddate,archivedby")
+	if err != nil {
+		logger.Fatalf("Failed to fetch %s: %v", issueKey, err)
+	}
+
+	fmt.Printf("\n%s: %s\n\n", issue.Key, issue.Fields.Summary)
+
+	if issue.Fields.ArchivedDate == "" {
+		fmt.Printf("%s is not archived.\n", issue.Key)
+		return exitOK
+	}
+
+	archivedBy := "unknown"
+	if user := issue.Fields.ArchivedBy; user != nil {
+		archivedBy = user.DisplayName
+		if user.EmailAddress != "" {
+			archivedBy += " <" + user.EmailAddress + ">"
+		}
+		archivedBy += " (account ID: " + user.AccountID + ")"
+	}
+
+	fmt.Printf("Archived at: %s\n", issue.Fields.ArchivedDate)
+	fmt.Printf("Archived by: %s\n", archivedBy)
+	return exitOK
+}
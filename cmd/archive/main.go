@@ -1,77 +1,475 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
 	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/messages"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/monitoring"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
 	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
 	"github.com/joho/godotenv"
 )
 
+// Exit codes of the one-shot mode. Existing cron entries depend on these.
+const (
+	exitOK       = 0
+	exitFailures = 1
+	// exitBudgetExhausted means MAX_API_CALLS was reached and the run stopped early
+	exitBudgetExhausted = 3
+	// exitInterrupted means SIGINT or SIGTERM stopped the run early
+	exitInterrupted = 130
+)
+
+// logger writes the command's own messages at the configured log level
+var logger logging.Logger
+
+// verbose logs at debug level whatever LOG_LEVEL says
+var verbose bool
+
 func main() {
 	// Configure logger
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
-	log.Println("Starting JIRA Cloud Bulk Archive Tool")
+	// One-shot is currently the only mode; the flag makes the contract explicit
+	// so existing cron entries keep working as other modes are added
+	flag.Bool("one-shot", false, "run a single env-driven search and archive pass, then exit (default)")
+
+	var opts runOptions
+	flag.IntVar(&opts.sample, "sample", 0, "print N randomly sampled matched issues and exit without archiving")
+	flag.BoolVar(&opts.sampleArchive, "sample-archive", false, "with --sample, archive only the sampled issues")
+	flag.StringVar(&opts.approved, "approved", "", "archive only the issues kept in an approved preview `file` (.csv or .xlsx)")
+	flag.StringVar(&opts.output, "output", "text", "run report on stdout: text, json, csv or none")
+	flag.StringVar(&opts.outputFile, "output-file", "", "write the run report to `file` instead of stdout")
+	flag.StringVar(&opts.jql, "jql", "", "select the issues matching this JQL query instead of the label (overrides JIRA_JQL)")
+	flag.StringVar(&opts.projects, "projects", "", "comma-separated project `keys` to archive in (overrides JIRA_PROJECT_KEY)")
+	flag.BoolVar(&opts.resume, "resume", false, "continue a stopped run from CHECKPOINT_FILE, skipping the issues it archived")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "search and batch as usual, then print what would be archived without changing anything")
+	flag.BoolVar(&opts.yes, "yes", false, "archive without asking for confirmation on a terminal")
+	flag.IntVar(&opts.confirmList, "confirm-list", 10, "list the first `N` matched issues when asking for confirmation")
+	flag.BoolVar(&opts.quiet, "quiet", false, "do not write logs to stderr (log files and syslog still receive them)")
+	flag.BoolVar(&verbose, "verbose", false, "log at debug level, including every API request (overrides LOG_LEVEL)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		command, ok := commands[flag.Arg(0)]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", flag.Arg(0))
+			usage()
+			os.Exit(2)
+		}
+		os.Exit(command.run(flag.Args()[1:]))
+	}
+
+	if opts.sample < 0 {
+		fmt.Fprintln(os.Stderr, "--sample must not be negative")
+		os.Exit(2)
+	}
+	if opts.confirmList < 0 {
+		fmt.Fprintln(os.Stderr, "--confirm-list must not be negative")
+		os.Exit(2)
+	}
+	if opts.sampleArchive && opts.sample == 0 {
+		fmt.Fprintln(os.Stderr, "--sample-archive requires --sample")
+		os.Exit(2)
+	}
+	switch opts.output {
+	case "text", "json", "csv", "none":
+	default:
+		fmt.Fprintf(os.Stderr, "--output must be text, json, csv or none, not %q\n", opts.output)
+		os.Exit(2)
+	}
+	if opts.outputFile != "" && opts.output == "none" {
+		fmt.Fprintln(os.Stderr, "--output-file requires --output text, json or csv")
+		os.Exit(2)
+	}
+
+	os.Exit(runOneShot(opts))
+}
+
+// runOptions holds command-line options that adjust a run
+type runOptions struct {
+	sample        int
+	sampleArchive bool
+	approved      string
+	// output is the format of the run report on stdout
+	output string
+	// outputFile receives the run report instead of stdout
+	outputFile string
+	// quiet keeps diagnostic logs off stderr
+	quiet bool
+	// dryRun overrides DRY_RUN
+	dryRun bool
+	// resume continues from CHECKPOINT_FILE
+	resume bool
+	// jql overrides JIRA_JQL
+	jql string
+	// projects overrides JIRA_PROJECT_KEY
+	projects string
+	// yes skips the confirmation prompt
+	yes bool
+	// confirmList is the number of issues listed by the prompt
+	confirmList int
+}
+
+// command is a subcommand run instead of the one-shot mode
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"bench":         {usage: "bench [--issues N,...] [--batch-sizes N,...] [--latency D]", run: runBench},
+	"digest":        {usage: "digest [--days N] [--format text|json]", run: runDigest},
+	"doctor":        {usage: "doctor", run: runDoctor},
+	"explain":       {usage: "explain ISSUE-KEY", run: runExplain},
+	"init":          {usage: "init [--file PATH] [--force]", run: runInit},
+	"list-archived": {usage: "list-archived --run RUN-ID", run: runListArchived},
+	"lookup":        {usage: "lookup ISSUE-KEY", run: runLookup},
+	"metrics":       {usage: "metrics", run: runMetrics},
+	"preview":       {usage: "preview [--file PATH.csv|PATH.xlsx]", run: runPreview},
+	"report":        {usage: "report timeline [--format text|csv|json] [--output PATH]", run: runReport},
+	"unarchive":     {usage: "unarchive --keys PATH [--yes]", run: runUnarchive},
+	"undo":          {usage: "undo --run RUN-ID [--yes]", run: runUndo},
+}
 
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [--one-shot] [--dry-run] [--resume] [--jql QUERY] [--projects KEYS] [--yes] [--sample N [--sample-archive]] [--approved FILE]\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "       %s %s\n", os.Args[0], commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "One-shot mode reads configuration from the environment (and .env),")
+	fmt.Fprintln(os.Stderr, "archives every matching issue and exits with 0 on success or 1 on any failure.")
+	fmt.Fprintln(os.Stderr)
+	flag.PrintDefaults()
+}
+
+// loadConfig loads .env (if present) and the environment configuration,
+// terminating the process on invalid configuration
+func loadConfig() *config.Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+		logger.Infof("No .env file found, using system environment variables")
 	} else {
-		log.Println("Loaded configuration from .env file")
+		logger.Infof("Loaded configuration from .env file")
 	}
 
 	// Load configuration from environment variables
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	if verbose {
+		level = slog.LevelDebug
+	}
+	logging.SetLevel(level)
+	transport.SetRateLimit(cfg.MaxRequestsPerSecond)
+	return cfg
+}
+
+// runOneShot searches for labeled issues, archives them and returns the
+// process exit code. Configuration errors terminate the process with 1.
+func runOneShot(opts runOptions) int {
+	if opts.quiet {
+		log.SetOutput(io.Discard)
+	}
+	var out io.Writer = os.Stdout
+	if opts.outputFile != "" {
+		f, err := os.Create(opts.outputFile)
+		if err != nil {
+			logger.Fatalf("Failed to create output file: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	logger.Infof("Starting JIRA Cloud Bulk Archive Tool")
+
+	if opts.jql != "" {
+		// --jql overrides JIRA_JQL; .env never overrides a set variable
+		os.Setenv("JIRA_JQL", opts.jql)
+	}
+	cfg := loadConfig()
+	if opts.dryRun {
+		cfg.DryRun = true
+	}
+	if opts.resume && cfg.CheckpointFile == "" {
+		logger.Fatalf("--resume requires CHECKPOINT_FILE")
+	}
+	if opts.projects != "" {
+		cfg.JiraProjectKey = strings.Join(jira.ParseProjectKeys(opts.projects), ",")
+		if err := cfg.Validate(); err != nil {
+			logger.Fatalf("Invalid --projects: %v", err)
+		}
+	}
+
+	closeLog, err := setupLogOutput(cfg, opts.quiet)
+	if err != nil {
+		logger.Fatalf("Failed to set up log output: %v", err)
+	}
+	defer closeLog()
+
+	// Keep the end of the log for the support bundle of a failed run
+	var logTail *logging.Tail
+	if cfg.SupportBundleDir != "" {
+		logTail = logging.NewTail(supportLogBytes)
+		log.SetOutput(io.MultiWriter(log.Writer(), logTail))
+	}
+
+	reporter, err := monitoring.NewReporter(cfg.SentryDSN, cfg.SentryEnvironment)
+	if err != nil {
+		logger.Fatalf("Failed to set up error reporting: %v", err)
 	}
+	defer reporter.Recover()
+	reporter.SetRunContext(map[string]interface{}{
+		"base_url":    cfg.JiraBaseURL,
+		"project_key": cfg.JiraProjectKey,
+		"label":       cfg.ArchiveLabel,
+	})
 
-	log.Printf("Configuration loaded successfully")
-	log.Printf("JIRA Base URL: %s", cfg.JiraBaseURL)
-	log.Printf("Project Key: %s", cfg.JiraProjectKey)
-	log.Printf("Archive Label: %s", cfg.ArchiveLabel)
-	log.Printf("Max Workers: %d", cfg.MaxWorkers)
+	notifiers := runner.Notifiers(cfg)
 
-	// Create JIRA client
-	client := jira.NewClient(cfg.JiraBaseURL, cfg.JiraEmail, cfg.JiraAPIToken)
+	// fatalf reports the failure before exiting, since Fatalf skips defers
+	fatalf := func(format string, args ...interface{}) {
+		err := fmt.Errorf(format, args...)
+		reporter.CaptureFailure(err)
+		runner.SendAlert(notifiers, cfg, "Bulk archive run failed", map[string]string{"error": err.Error()})
+		writeSupportBundle(cfg, logTail, nil, err)
+		logger.Fatalf("%v", err)
+	}
+
+	catalog := messages.New(cfg.TemplateDir, cfg.Locale)
+	if err := catalog.Check(); err != nil {
+		fatalf("Invalid message templates: %v", err)
+	}
 
-	// Search for issues with the archive label
-	log.Printf("Searching for issues with label '%s' in project '%s'...", cfg.ArchiveLabel, cfg.JiraProjectKey)
-	issues, err := client.GetAllIssuesByLabel(cfg.JiraProjectKey, cfg.ArchiveLabel)
+	logger.Infof("Configuration loaded successfully")
+	logger.Infof("JIRA Base URL: %s", cfg.JiraBaseURL)
+	logger.Infof("Project Key: %s", cfg.JiraProjectKey)
+	logger.Infof("Archive Label: %s", cfg.ArchiveLabel)
+	logger.Infof("Max Workers: %d", cfg.MaxWorkers)
+
+	progress, closeProgress, err := openProgress(cfg)
 	if err != nil {
-		log.Fatalf("Failed to search for issues: %v", err)
+		fatalf("Failed to open progress output: %v", err)
 	}
+	defer closeProgress()
 
-	log.Printf("Found %d issues to archive", len(issues))
+	ctx := interruptContext()
+	declined := false
+	result, err := runner.Run(ctx, cfg, runner.Options{
+		Progress: progress,
+		Resume:   opts.resume,
+		Filter: func(issues []jira.Issue) ([]jira.Issue, error) {
+			issues, err := filterIssues(opts, issues)
+			if err != nil || len(issues) == 0 || cfg.DryRun || opts.yes {
+				return issues, err
+			}
+			issues, err = confirmArchive(cfg.JiraProjectKey, issues, opts.confirmList)
+			if errors.Is(err, errNotConfirmed) {
+				// End the run without archiving; declining is not a failure to alert about
+				declined = true
+				return nil, nil
+			}
+			return issues, err
+		},
+	})
+	if declined {
+		logger.Infof("Archive cancelled; no issues were changed")
+		return exitOK
+	}
+	if errors.Is(err, jira.ErrAPIBudgetExhausted) && result == nil {
+		return exitBudgetExhausted
+	}
+	if errors.Is(err, context.Canceled) && result == nil {
+		logger.Infof("Run interrupted before archiving; no issues were changed")
+		return exitInterrupted
+	}
+	if result == nil {
+		// Run has already alerted
+		err := fmt.Errorf("Run failed: %w", err)
+		reporter.CaptureFailure(err)
+		writeSupportBundle(cfg, logTail, nil, err)
+		logger.Fatalf("%v", err)
+	}
+	if result.DryRun {
+		printPlan(out, opts.output, result)
+		return exitOK
+	}
+	if !result.Archived() {
+		return exitOK
+	}
+	archiveErr := err
 
-	if len(issues) == 0 {
-		log.Println("No issues to archive. Exiting.")
-		os.Exit(0)
+	printReport(out, catalog, opts.output, result)
+	writeReports(catalog, cfg.ReportFiles, result)
+	if err := writeMetricsFile(cfg); err != nil {
+		logger.Warnf("Failed to write metrics: %v", err)
 	}
 
-	// Create archiver and process issues concurrently
-	archiver := worker.NewArchiver(client, cfg.MaxWorkers)
-	results := archiver.ArchiveIssues(issues)
+	if errors.Is(archiveErr, worker.ErrStoppedOnBudget) {
+		return exitBudgetExhausted
+	}
+	if errors.Is(archiveErr, context.Canceled) {
+		logResumePoint(result)
+		return exitInterrupted
+	}
 
-	// Print summary
-	worker.PrintSummary(results)
+	if archiveErr != nil {
+		reporter.CaptureFailure(archiveErr)
+		logger.Errorf("Run aborted: %v", archiveErr)
+		writeSupportBundle(cfg, logTail, result, archiveErr)
+		return exitFailures
+	}
 
 	// Exit with error code if any failures occurred
-	hasFailures := false
-	for _, result := range results {
-		if !result.Success {
-			hasFailures = true
-			break
+	if result.Failed > 0 {
+		reporter.CaptureFailure(fmt.Errorf("%d of %d issues failed to archive", result.Failed, result.Total))
+		logger.Warnf("Completed with errors")
+		writeSupportBundle(cfg, logTail, result, nil)
+		return exitFailures
+	}
+
+	logger.Infof("All issues archived successfully!")
+	return exitOK
+}
+
+// interruptContext returns a context cancelled by the first SIGINT or
+// SIGTERM, so the run stops after the current batch. A second signal
+// terminates the process as usual.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		logger.Infof("Received %v: stopping after the current batch (send it again to quit immediately)", sig)
+		cancel()
+	}()
+	return ctx
+}
+
+// logResumePoint tells an interrupted run's user where it stopped. Archived
+// issues no longer match the search, so running again resumes there.
+func logResumePoint(result *runner.RunResult) {
+	logger.Infof("Run interrupted after %d of %d issues", result.Total, result.Total+len(result.Remaining))
+	if len(result.Remaining) == 0 {
+		return
+	}
+	logger.Infof("Resume point: %s (%d issues not processed); run the tool again to archive them", result.Remaining[0], len(result.Remaining))
+}
+
+// filterIssues applies --approved and --sample to the selected issues. An
+// empty result ends the run without archiving.
+func filterIssues(opts runOptions, issues []jira.Issue) ([]jira.Issue, error) {
+	if opts.approved != "" {
+		var err error
+		issues, err = applyApproval(issues, opts.approved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply approved preview: %w", err)
+		}
+		logger.Infof("Approved preview %s: archiving %d issues", opts.approved, len(issues))
+		if len(issues) == 0 {
+			logger.Infof("No approved issues to archive. Exiting.")
+			return nil, nil
 		}
 	}
 
-	if hasFailures {
-		log.Println("Completed with errors")
-		os.Exit(1)
+	if opts.sample > 0 {
+		sample := sampleIssues(issues, opts.sample)
+		printSample(sample, len(issues))
+		if !opts.sampleArchive {
+			logger.Infof("Sample mode: no issues were archived")
+			return nil, nil
+		}
+		logger.Infof("Sample mode: archiving only the %d sampled issues", len(sample))
+		issues = sample
 	}
+	return issues, nil
+}
 
-	log.Println("All issues archived successfully!")
+// setupLogOutput adds the rotating log file and the system log, if
+// configured, next to stderr. quiet leaves stderr out.
+func setupLogOutput(cfg *config.Config, quiet bool) (func(), error) {
+	var writers []io.Writer
+	if !quiet {
+		writers = append(writers, os.Stderr)
+	}
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	if cfg.LogFile != "" {
+		file, err := logging.NewRotatingFile(
+			cfg.LogFile,
+			int64(cfg.LogMaxSizeMB)*1024*1024,
+			time.Duration(cfg.LogMaxAgeDays)*24*time.Hour,
+			cfg.LogMaxBackups,
+		)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, file)
+		closers = append(closers, file)
+	}
+
+	if cfg.SyslogTarget != "" {
+		system, err := logging.NewSystemWriter(cfg.SyslogTarget, cfg.SyslogAddress, cfg.SyslogTag)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		writers = append(writers, system)
+		closers = append(closers, system)
+	}
+
+	log.SetOutput(io.MultiWriter(writers...))
+	return closeAll, nil
+}
+
+// openProgress opens the NDJSON progress side channel, if configured.
+// The returned Progress is nil (and safe to use) when progress is disabled.
+func openProgress(cfg *config.Config) (*worker.Progress, func(), error) {
+	switch {
+	case cfg.ProgressFile != "":
+		f, err := os.OpenFile(cfg.ProgressFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return worker.NewProgress(f), func() { f.Close() }, nil
+	case cfg.ProgressFD > 0:
+		f := os.NewFile(uintptr(cfg.ProgressFD), "progress")
+		if f == nil {
+			return nil, nil, fmt.Errorf("invalid file descriptor %d", cfg.ProgressFD)
+		}
+		return worker.NewProgress(f), func() { f.Close() }, nil
+	default:
+		return nil, func() {}, nil
+	}
 }
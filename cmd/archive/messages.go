@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/messages"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// printMessage renders a template to w, logging render failures
+func printMessage(w io.Writer, catalog *messages.Catalog, name string, data any) {
+	text, err := catalog.Render(name, data)
+	if err != nil {
+		logger.Warnf("Failed to render %s: %v", name, err)
+		return
+	}
+	fmt.Fprint(w, text)
+}
+
+// writeReports renders the Markdown or HTML report, chosen by extension,
+// into every configured report file
+func writeReports(catalog *messages.Catalog, paths []string, result *worker.RunResult) {
+	for _, path := range paths {
+		name := messages.ReportMarkdown
+		if filepath.Ext(path) == ".html" {
+			name = messages.ReportHTML
+		}
+
+		text, err := catalog.Render(name, result)
+		if err != nil {
+			logger.Warnf("Failed to render %s: %v", name, err)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			logger.Warnf("Failed to write report: %v", err)
+			continue
+		}
+		logger.Infof("Wrote report to %s", path)
+	}
+}
+
+// printReport writes the run report to w in the requested format: the
+// rendered summary and detail sections, a single JSON document, one CSV
+// row per issue, or nothing. Diagnostics always go to the log, never to w.
+func printReport(w io.Writer, catalog *messages.Catalog, format string, result *worker.RunResult) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			logger.Warnf("Failed to write report: %v", err)
+		}
+	case "csv":
+		if err := worker.WriteResultsCSV(w, result); err != nil {
+			logger.Warnf("Failed to write report: %v", err)
+		}
+	case "none":
+	default:
+		printMessage(w, catalog, messages.Summary, result)
+		worker.PrintProjectSummary(w, result.Projects)
+		worker.PrintServiceDeskSummary(w, result.ServiceDesk)
+		worker.PrintRequestStats(w, result.Requests)
+		worker.PrintEscalations(w, result.Escalations)
+		if result.Audit != nil {
+			worker.PrintAuditCrossCheck(w, result.Audit)
+		}
+	}
+}
+
+// printPlan writes a dry run's planned batches to w in the --output format
+func printPlan(w io.Writer, format string, result *worker.RunResult) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			logger.Warnf("Failed to write report: %v", err)
+		}
+	case "csv":
+		if err := worker.WritePlanCSV(w, result.Plan); err != nil {
+			logger.Warnf("Failed to write report: %v", err)
+		}
+	case "none":
+	default:
+		worker.PrintPlan(w, result.Plan)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+)
+
+// runMetrics prints the run history as Prometheus metrics, e.g. to push
+// them to a Pushgateway from cron
+func runMetrics(args []string) int {
+	fs := flag.NewFlagSet("metrics", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s metrics\n", os.Args[0])
+		return 2
+	}
+
+	cfg := loadConfig()
+	if cfg.HistoryFile == "" {
+		fmt.Fprintln(os.Stderr, "HISTORY_FILE is required for metrics")
+		return 2
+	}
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read run history: %v\n", err)
+		return exitFailures
+	}
+	if err := history.WriteMetrics(os.Stdout, runs); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write metrics: %v\n", err)
+		return exitFailures
+	}
+	return exitOK
+}
+
+// writeMetricsFile rewrites METRICS_FILE from the run history. The file is
+// replaced atomically so the textfile collector never reads a partial file.
+func writeMetricsFile(cfg *config.Config) error {
+	if cfg.MetricsFile == "" {
+		return nil
+	}
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		return fmt.Errorf("failed to read run history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cfg.MetricsFile), ".metrics-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := history.WriteMetrics(tmp, runs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cfg.MetricsFile)
+}
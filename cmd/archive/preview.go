@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/sheet"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+)
+
+// previewHeader is the column layout of preview spreadsheets
+var previewHeader = []string{"Key", "Summary", "Status", "Assignee", "Updated"}
+
+// runPreview lists the issues the current configuration would archive,
+// optionally writing them to a CSV or XLSX file for stakeholder sign-off
+func runPreview(args []string) int {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	file := fs.String("file", "", "write the matched issues to a .csv or .xlsx file")
+	fs.Parse(args)
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	source, issues, err := runner.Select(cfg, client)
+	if err != nil {
+		logger.Fatalf("Failed to search for issues: %v", err)
+	}
+	logger.Infof("Found %d issues to archive from %s", len(issues), source.Name())
+
+	rows := [][]string{previewHeader}
+	for _, issue := range issues {
+		rows = append(rows, previewRow(issue))
+	}
+
+	if *file != "" {
+		if err := sheet.Write(*file, rows); err != nil {
+			logger.Fatalf("Failed to write preview: %v", err)
+		}
+		if err := writePreviewManifest(*file, issues); err != nil {
+			logger.Fatalf("Failed to write preview manifest: %v", err)
+		}
+		logger.Infof("Wrote %d issues to %s (keys recorded in %s)", len(issues), *file, previewManifestPath(*file))
+		return exitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", row[0], row[2], row[4], row[3], row[1])
+	}
+	w.Flush()
+	fmt.Printf("\n%d issues would be archived\n", len(issues))
+	return exitOK
+}
+
+// previewManifest records the issues a preview file was written with, so
+// that an approved copy of the file cannot add issues to it
+type previewManifest struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Keys      []string  `json:"keys"`
+}
+
+// previewManifestPath returns the manifest kept next to a preview file
+func previewManifestPath(path string) string {
+	return path + ".keys.json"
+}
+
+// writePreviewManifest records the keys of issues next to the preview file
+func writePreviewManifest(path string, issues []jira.Issue) error {
+	manifest := previewManifest{CreatedAt: time.Now().UTC(), Keys: make([]string, len(issues))}
+	for i, issue := range issues {
+		manifest.Keys[i] = issue.Key
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(previewManifestPath(path), append(data, '\n'), 0o644)
+}
+
+// readPreviewManifest returns the keys recorded for the preview file
+func readPreviewManifest(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(previewManifestPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found; approve a file written by preview --file, keeping its manifest next to it", previewManifestPath(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest previewManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid preview manifest %s: %w", previewManifestPath(path), err)
+	}
+	keys := make(map[string]bool, len(manifest.Keys))
+	for _, key := range manifest.Keys {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// previewRow returns the spreadsheet columns for an issue
+func previewRow(issue jira.Issue) []string {
+	status := ""
+	if issue.Fields.Status != nil {
+		status = issue.Fields.Status.Name
+	}
+	assignee := ""
+	if issue.Fields.Assignee != nil {
+		assignee = issue.Fields.Assignee.DisplayName
+	}
+	return []string{issue.Key, issue.Fields.Summary, status, assignee, issue.Fields.Updated}
+}
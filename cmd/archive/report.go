@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+)
+
+// timelineBarWidth is the width of the longest bar in the text chart
+const timelineBarWidth = 40
+
+// reports are the report kinds of the report command
+var reports = map[string]func(args []string) int{
+	"timeline": runTimelineReport,
+}
+
+// runReport dispatches to a report kind
+func runReport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s report timeline [--format text|csv|json] [--output PATH]\n", os.Args[0])
+		return 2
+	}
+	run, ok := reports[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown report %q\n", args[0])
+		return 2
+	}
+	return run(args[1:])
+}
+
+// runTimelineReport prints archived counts per project per month from the
+// run history, as a text chart or as a CSV/JSON series
+func runTimelineReport(args []string) int {
+	fs := flag.NewFlagSet("report timeline", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text, csv or json")
+	output := fs.String("output", "", "write the report to a file instead of stdout")
+	fs.Parse(args)
+
+	cfg := loadConfig()
+	if cfg.HistoryFile == "" {
+		logger.Fatalf("HISTORY_FILE is required for reports")
+	}
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		logger.Fatalf("Failed to read run history: %v", err)
+	}
+	points := history.Timeline(runs)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			logger.Fatalf("Failed to create report file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "text":
+		writeTimelineChart(w, points)
+	case "csv":
+		err = writeTimelineCSV(w, points)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(points)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q\n", *format)
+		return 2
+	}
+	if err != nil {
+		logger.Fatalf("Failed to write report: %v", err)
+	}
+	return exitOK
+}
+
+// writeTimelineChart draws one bar per project and month
+func writeTimelineChart(w io.Writer, points []history.TimelinePoint) {
+	if len(points) == 0 {
+		fmt.Fprintln(w, "No archived issues in the run history.")
+		return
+	}
+
+	largest := 0
+	for _, p := range points {
+		largest = max(largest, p.Archived)
+	}
+
+	for i, p := range points {
+		if i == 0 || p.Project != points[i-1].Project {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, p.Project)
+		}
+		bar := max(1, p.Archived*timelineBarWidth/largest)
+		fmt.Fprintf(w, "  %s  %-*s  %d (total %d)\n", p.Month, timelineBarWidth, strings.Repeat("#", bar), p.Archived, p.Cumulative)
+	}
+}
+
+func writeTimelineCSV(w io.Writer, points []history.TimelinePoint) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "project", "archived", "cumulative"})
+	for _, p := range points {
+		cw.Write([]string{p.Month, p.Project, strconv.Itoa(p.Archived), strconv.Itoa(p.Cumulative)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// sampleIssues returns n randomly chosen issues, or all of them if n >= len(issues)
+func sampleIssues(issues []jira.Issue, n int) []jira.Issue {
+	if n >= len(issues) {
+		return issues
+	}
+
+	sample := make([]jira.Issue, 0, n)
+	for _, i := range rand.Perm(len(issues))[:n] {
+		sample = append(sample, issues[i])
+	}
+	jira.SortIssues(sample)
+	return sample
+}
+
+// printSample prints the details policy authors need to spot-check a
+// selection to stderr, keeping stdout for the report
+func printSample(sample []jira.Issue, total int) {
+	fmt.Fprintf(os.Stderr, "\nSample of %d out of %d matched issues:\n\n", len(sample), total)
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tSTATUS\tUPDATED\tASSIGNEE\tSUMMARY")
+	for _, issue := range sample {
+		status := "-"
+		if issue.Fields.Status != nil {
+			status = issue.Fields.Status.Name
+		}
+		assignee := "Unassigned"
+		if issue.Fields.Assignee != nil {
+			assignee = issue.Fields.Assignee.DisplayName
+		}
+		updated := issue.Fields.Updated
+		if updated == "" {
+			updated = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", issue.Key, status, updated, assignee, issue.Fields.Summary)
+	}
+	w.Flush()
+	fmt.Fprintln(os.Stderr)
+}
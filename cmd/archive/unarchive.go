@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/selector"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// runUnarchive restores the archived issues listed in a key file after
+// asking for confirmation, then reports the outcome per issue. Unlike undo
+// it does not need the run history, so it also reverses runs that were not
+// recorded or archives made by hand.
+func runUnarchive(args []string) int {
+	fs := flag.NewFlagSet("unarchive", flag.ExitOnError)
+	keysPath := fs.String("keys", "", "file listing the issue keys to restore, one per line (- for stdin)")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Parse(args)
+
+	if *keysPath == "" || fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s unarchive --keys PATH [--yes]\n", os.Args[0])
+		return 2
+	}
+	// Stdin holds the keys, so it cannot also answer the confirmation
+	if *keysPath == "-" && !*yes {
+		fmt.Fprintln(os.Stderr, "--yes is required when reading keys from stdin")
+		return 2
+	}
+
+	raw, err := selector.ReadKeyFile(*keysPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	keys, invalid := jira.NormalizeKeys(raw)
+	if len(invalid) > 0 {
+		fmt.Fprintf(os.Stderr, "%s has %d invalid issue keys: %s\n", *keysPath, len(invalid), strings.Join(invalid, ", "))
+		return 2
+	}
+	if len(keys) == 0 {
+		logger.Infof("%s lists no issues to restore", *keysPath)
+		return exitOK
+	}
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	if !*yes && !confirm(fmt.Sprintf("Unarchive %d issues listed in %s?", len(keys), *keysPath)) {
+		logger.Infof("Unarchive cancelled")
+		return exitOK
+	}
+
+	archiver := worker.NewArchiver(client, cfg.MaxWorkers)
+	results := archiver.UnarchiveIssues(keys)
+	if failed := printUnarchiveSummary("Unarchive Summary", "Keys: "+*keysPath, results, ""); failed > 0 {
+		return exitFailures
+	}
+	return exitOK
+}
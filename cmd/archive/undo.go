@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// runUndo unarchives every issue archived by one run after asking for
+// confirmation, then reports the outcome per issue
+func runUndo(args []string) int {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	runID := fs.String("run", "", "run ID to undo")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Parse(args)
+
+	if *runID == "" || fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s undo --run RUN-ID [--yes]\n", os.Args[0])
+		return 2
+	}
+
+	cfg := loadConfig()
+	client := runner.NewClient(cfg)
+
+	run, keys, unconfirmed := runArchivedKeys(cfg, client, *runID)
+	if unconfirmed > 0 {
+		logger.Infof("Leaving %d issues that could not be confirmed as archived by run %s", unconfirmed, run.ID)
+	}
+	if len(keys) == 0 {
+		logger.Infof("Run %s has no archived issues to restore", run.ID)
+		return exitOK
+	}
+
+	fmt.Printf("Run %s (%s, %s) archived %d issues.\n", run.ID, run.StartedAt.Format("2006-01-02 15:04:05 MST"), run.Selector, len(keys))
+	if !*yes && !confirm(fmt.Sprintf("Unarchive %d issues?", len(keys))) {
+		logger.Infof("Undo cancelled")
+		return exitOK
+	}
+
+	archiver := worker.NewArchiver(client, cfg.MaxWorkers)
+	results := archiver.UnarchiveIssues(keys)
+
+	trailer := ""
+	if unconfirmed > 0 {
+		trailer = fmt.Sprintf("Not confirmed (left archived): %d", unconfirmed)
+	}
+	if failed := printUnarchiveSummary("Undo Summary", "Run: "+run.ID, results, trailer); failed > 0 {
+		return exitFailures
+	}
+	return exitOK
+}
+
+// printUnarchiveSummary prints the failed issues and the counts, with lead
+// and trailer lines around the counts if set, and returns the failures
+func printUnarchiveSummary(title, lead string, results []worker.ArchiveResult, trailer string) int {
+	restored, failed := 0, 0
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", 50))
+	for _, r := range results {
+		if r.Success {
+			restored++
+			continue
+		}
+		failed++
+		fmt.Printf("Failed: %s - %v\n", r.IssueKey, r.Error)
+	}
+	fmt.Println()
+	if lead != "" {
+		fmt.Println(lead)
+	}
+	fmt.Printf("Successfully unarchived: %d\n", restored)
+	fmt.Printf("Failed: %d\n", failed)
+	if trailer != "" {
+		fmt.Println(trailer)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	return failed
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -2,4 +2,12 @@ module github.com/c_yamada/jira_cloud_bulk_archive
 
 go 1.23.5
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/getsentry/sentry-go v0.31.1
+	github.com/joho/godotenv v1.5.1
+)
+
+require (
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
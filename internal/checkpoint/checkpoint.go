@@ -0,0 +1,130 @@
+// Package checkpoint persists the progress of a run, so that a run stopped
+// by a crash or a network failure can resume without re-sending the
+// batches it already archived.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+)
+
+// Checkpoint is the progress of a run, rewritten after every batch
+type Checkpoint struct {
+	// RunID is the first run that archived issues under this checkpoint
+	RunID      string    `json:"runId"`
+	Selector   string    `json:"selector"`
+	PolicyHash string    `json:"policyHash"`
+	StartedAt  time.Time `json:"startedAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Search  Search  `json:"search"`
+	Batches []Batch `json:"batches,omitempty"`
+	// Outcomes maps each processed issue key to history.StatusArchived,
+	// StatusFailed or StatusSkipped
+	Outcomes map[string]string `json:"outcomes,omitempty"`
+}
+
+// Search records how far the issue search got
+type Search struct {
+	JQL string `json:"jql,omitempty"`
+	// NextPageToken is the token of the page after the last one fetched
+	NextPageToken string `json:"nextPageToken,omitempty"`
+	Issues        int    `json:"issues"`
+	Completed     bool   `json:"completed"`
+}
+
+// Batch is the outcome of one archive batch
+type Batch struct {
+	// RunID and Number identify the batch; a resumed run numbers its
+	// batches from 1 again
+	RunID      string    `json:"runId"`
+	Number     int       `json:"number"`
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+	Skipped    int       `json:"skipped"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// New starts an empty checkpoint
+func New(now time.Time) *Checkpoint {
+	return &Checkpoint{StartedAt: now, UpdatedAt: now, Outcomes: make(map[string]string)}
+}
+
+// Load reads the checkpoint at path. It returns nil without an error if
+// there is none.
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if c.Outcomes == nil {
+		c.Outcomes = make(map[string]string)
+	}
+	return &c, nil
+}
+
+// Save writes the checkpoint to path, replacing the previous one
+// atomically so that a crash never leaves a truncated checkpoint
+func (c *Checkpoint) Save(path string, now time.Time) error {
+	c.UpdatedAt = now
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove deletes the checkpoint at path, if any
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Archived reports whether the checkpoint recorded key as archived
+func (c *Checkpoint) Archived(key string) bool {
+	return c.Outcomes[key] == history.StatusArchived
+}
+
+// AddBatch records the outcomes of a finished batch by issue key
+func (c *Checkpoint) AddBatch(runID string, number int, outcomes map[string]string, finishedAt time.Time) {
+	batch := Batch{RunID: runID, Number: number, FinishedAt: finishedAt}
+	for key, outcome := range outcomes {
+		c.Outcomes[key] = outcome
+		switch outcome {
+		case history.StatusArchived:
+			batch.Succeeded++
+		case history.StatusSkipped:
+			batch.Skipped++
+		default:
+			batch.Failed++
+		}
+	}
+	c.Batches = append(c.Batches, batch)
+}
@@ -3,7 +3,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/export"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
 )
 
 // Config holds all configuration for the application
@@ -11,20 +18,345 @@ type Config struct {
 	JiraBaseURL    string
 	JiraEmail      string
 	JiraAPIToken   string
-	JiraProjectKey string
+	JiraProjectKey string // comma-separated to archive in several projects
 	ArchiveLabel   string
 	MaxWorkers     int
+
+	// File holding the API token when JIRA_API_TOKEN is not set
+	JiraAPITokenFile string
+
+	// How requests authenticate: basic (email and API token), oauth (OAuth
+	// 2.0 access token) or pat (personal access token). The bearer methods
+	// take the token from JIRA_API_TOKEN and need no email.
+	JiraAuthMethod string
+
+	// Selector overrides the label search with another selection source
+	Selector string
+	// JQL overrides the label search with a plain JQL query
+	JQL string
+
+	// Age criteria narrowing the label search or JQL: issues last updated
+	// at least this many days ago, and resolved before a date (2006-01-02)
+	// or a number of days ago ("90d")
+	ArchiveOlderThanDays  int
+	ArchiveResolvedBefore string
+
+	// Freeze calendar: runs are skipped while a freeze window is active
+	FreezeDates       string
+	FreezeCalendarURL string
+
+	// NDJSON progress events go to a file or an inherited file descriptor
+	ProgressFile string
+	ProgressFD   int
+
+	// Minimum level logged: debug, info, warn or error
+	LogLevel string
+
+	// Log file output in addition to stderr, with size-based rotation
+	LogFile       string
+	LogMaxSizeMB  int
+	LogMaxAgeDays int
+	LogMaxBackups int
+
+	// System log output (syslog or journald) in addition to stderr
+	SyslogTarget  string
+	SyslogAddress string
+	SyslogTag     string
+
+	// Sentry error reporting for panics and run-level failures
+	SentryDSN         string
+	SentryEnvironment string
+
+	// Incident alerts on run failure
+	PagerDutyRoutingKey string
+	OpsgenieAPIKey      string
+	OpsgenieAPIURL      string
+	AlertFailureRate    float64
+
+	// Abort the run early when the failure rate suggests systemic breakage
+	AbortFailureRate float64
+	AbortMinBatches  int
+
+	// Archive and verify a small canary batch before the full run
+	CanarySize int
+
+	// Verify every batch after archiving, with this many concurrent searches
+	VerifyArchived    bool
+	VerifyConcurrency int
+
+	// Reconcile results against Jira's audit log after the run
+	AuditCrossCheck bool
+
+	// Skip issues the archive API is known to reject before each batch
+	EligibilityPreflight bool
+
+	// Skip issues with an issue security level before each batch: all of
+	// them, or those whose level is not listed (names or IDs)
+	ArchiveSkipSecured    bool
+	ArchiveSecurityLevels []string
+
+	// Issues with any of these labels, statuses or issue types are never
+	// archived: left out of searches and skipped before each batch
+	ExcludeLabels     []string
+	ExcludeStatuses   []string
+	ExcludeIssueTypes []string
+
+	// What happens to Jira Service Management requests: archive, skip or
+	// archive only resolved requests
+	ServiceDeskPolicy string
+
+	// Keep each archive batch within a single project
+	PartitionByProject bool
+
+	// Send issue IDs rather than keys to the bulk archive API
+	ArchiveByID bool
+
+	// Export the full JSON of each issue before archiving it, as one file
+	// per issue (json) or one tarball per run (tar), with this many
+	// concurrent downloads (empty directory disables)
+	ExportDir     string
+	ExportFormat  string
+	ExportWorkers int
+
+	// Templated comment added to each issue right before archiving
+	ArchiveComment     string
+	ArchiveCommentRate float64
+
+	// Label permanently failed issues and drop the trigger label
+	FailureLabel       string
+	RemoveTriggerLabel bool
+
+	// Run history file and the consecutive failures that trigger escalation
+	HistoryFile    string
+	EscalationRuns int
+
+	// Prometheus text-format metrics derived from the run history
+	MetricsFile string
+
+	// Progress of the current run, rewritten after every batch for --resume
+	CheckpointFile string
+
+	// Leave out issues that failed permanently within this many days (0 disables)
+	SkipIneligibleDays int
+
+	// Flag metrics at least RegressionFactor times their average over the
+	// last RegressionRuns comparable runs (0 runs disables)
+	RegressionRuns   int
+	RegressionFactor float64
+
+	// Issue entity property recording the run that archived each issue
+	RunPropertyKey string
+
+	// Locale and override directory of summary, report and alert templates
+	Locale      string
+	TemplateDir string
+
+	// Markdown (.md) and HTML (.html) report files written after each run
+	ReportFiles []string
+
+	// API call budget and retries
+	MaxAPICalls int
+	MaxRetries  int
+
+	// Backoff before the first retry in milliseconds, doubling per attempt,
+	// and the fraction of random delay added to each wait
+	RetryBaseDelayMS int
+	RetryJitter      float64
+
+	// Requests per second sent to each host by all HTTP clients together
+	// (0 = unlimited)
+	MaxRequestsPerSecond float64
+
+	// JIRA_RATE_LIMIT_RPS, an alias of MAX_REQUESTS_PER_SECOND used when
+	// that is not set. Setting both to different values is an error.
+	JiraRateLimitRPS float64
+
+	// Jira requests taking at least this many milliseconds are logged with
+	// their request ID (0 = disabled)
+	SlowRequestMS int
+
+	// A search GET unanswered after this many milliseconds is sent again and
+	// the first answer is used (0 = disabled)
+	SearchHedgeMS int
+
+	// Directory caching project and label metadata between runs, revalidated
+	// with ETags (empty = disabled)
+	MetadataCacheDir string
+
+	// Seconds fields, statuses and issue types are reused before they are
+	// looked up again (0 = every time)
+	MetadataTTLSeconds int
+
+	// Issues requested per search page
+	SearchPageSize int
+
+	// Extra fields and expand options passed through to searches
+	SearchFields []string
+	SearchExpand []string
+
+	// Search and batch without archiving or changing any issue
+	DryRun bool
+
+	// Reject unknown variables and unparseable values instead of ignoring them
+	Strict bool
+
+	// Directory to write a support bundle to when a run fails (empty disables)
+	SupportBundleDir string
+
+	// Warehouse sink for per-issue outcomes: bigquery or http (empty disables)
+	WarehouseSink   string
+	WarehouseTable  string
+	WarehouseURL    string
+	WarehouseMethod string
+	WarehouseFormat string
+	WarehouseToken  string
 }
 
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
+	resetEnvReads()
 	config := &Config{
-		JiraBaseURL:    os.Getenv("JIRA_BASE_URL"),
-		JiraEmail:      os.Getenv("JIRA_EMAIL"),
-		JiraAPIToken:   os.Getenv("JIRA_API_TOKEN"),
-		JiraProjectKey: os.Getenv("JIRA_PROJECT_KEY"),
+		JiraBaseURL:    lookupEnv("JIRA_BASE_URL"),
+		JiraEmail:      lookupEnv("JIRA_EMAIL"),
+		JiraAPIToken:   lookupEnv("JIRA_API_TOKEN"),
+		JiraProjectKey: lookupEnv("JIRA_PROJECT_KEY"),
 		ArchiveLabel:   getEnvOrDefault("ARCHIVE_LABEL", "archive"),
 		MaxWorkers:     getIntEnvOrDefault("MAX_WORKERS", 5),
+
+		JiraAPITokenFile: lookupEnv("JIRA_API_TOKEN_FILE"),
+		JiraAuthMethod:   getEnvOrDefault("JIRA_AUTH_METHOD", jira.AuthBasic),
+
+		Selector: lookupEnv("SELECTOR"),
+		JQL:      lookupEnv("JIRA_JQL"),
+
+		ArchiveOlderThanDays:  getIntEnvOrDefault("ARCHIVE_OLDER_THAN_DAYS", 0),
+		ArchiveResolvedBefore: lookupEnv("ARCHIVE_RESOLVED_BEFORE"),
+
+		FreezeDates:       lookupEnv("FREEZE_DATES"),
+		FreezeCalendarURL: lookupEnv("FREEZE_CALENDAR_URL"),
+
+		ProgressFile: lookupEnv("PROGRESS_FILE"),
+		ProgressFD:   getIntEnvOrDefault("PROGRESS_FD", 0),
+
+		LogLevel: getEnvOrDefault("LOG_LEVEL", "info"),
+
+		LogFile:       lookupEnv("LOG_FILE"),
+		LogMaxSizeMB:  getIntEnvOrDefault("LOG_MAX_SIZE_MB", 100),
+		LogMaxAgeDays: getIntEnvOrDefault("LOG_MAX_AGE_DAYS", 0),
+		LogMaxBackups: getIntEnvOrDefault("LOG_MAX_BACKUPS", 5),
+
+		SyslogTarget:  lookupEnv("SYSLOG_TARGET"),
+		SyslogAddress: lookupEnv("SYSLOG_ADDRESS"),
+		SyslogTag:     getEnvOrDefault("SYSLOG_TAG", "jira-bulk-archive"),
+
+		SentryDSN:         lookupEnv("SENTRY_DSN"),
+		SentryEnvironment: lookupEnv("SENTRY_ENVIRONMENT"),
+
+		PagerDutyRoutingKey: lookupEnv("PAGERDUTY_ROUTING_KEY"),
+		OpsgenieAPIKey:      lookupEnv("OPSGENIE_API_KEY"),
+		OpsgenieAPIURL:      getEnvOrDefault("OPSGENIE_API_URL", "https://api.opsgenie.com"),
+		AlertFailureRate:    getFloatEnvOrDefault("ALERT_FAILURE_RATE", 0),
+
+		AbortFailureRate: getFloatEnvOrDefault("ABORT_FAILURE_RATE", 0),
+		AbortMinBatches:  getIntEnvOrDefault("ABORT_MIN_BATCHES", 1),
+
+		CanarySize: getIntEnvOrDefault("CANARY_SIZE", 0),
+
+		VerifyArchived:    getBoolEnvOrDefault("VERIFY_ARCHIVED", false),
+		VerifyConcurrency: getIntEnvOrDefault("VERIFY_CONCURRENCY", 4),
+
+		AuditCrossCheck: getBoolEnvOrDefault("AUDIT_CROSS_CHECK", false),
+
+		EligibilityPreflight: getBoolEnvOrDefault("ELIGIBILITY_PREFLIGHT", false),
+
+		ArchiveSkipSecured:    getBoolEnvOrDefault("ARCHIVE_SKIP_SECURED", false),
+		ArchiveSecurityLevels: getListEnv("ARCHIVE_SECURITY_LEVELS"),
+
+		ExcludeLabels:     getListEnv("EXCLUDE_LABELS"),
+		ExcludeStatuses:   getListEnv("EXCLUDE_STATUSES"),
+		ExcludeIssueTypes: getListEnv("EXCLUDE_ISSUE_TYPES"),
+
+		ServiceDeskPolicy: getEnvOrDefault("SERVICE_DESK_POLICY", "archive"),
+
+		PartitionByProject: getBoolEnvOrDefault("PARTITION_BY_PROJECT", true),
+
+		ArchiveByID: getBoolEnvOrDefault("ARCHIVE_BY_ID", true),
+
+		ExportDir:     lookupEnv("EXPORT_DIR"),
+		ExportFormat:  getEnvOrDefault("EXPORT_FORMAT", export.FormatJSON),
+		ExportWorkers: getIntEnvOrDefault("EXPORT_WORKERS", 4),
+
+		ArchiveComment:     lookupEnv("ARCHIVE_COMMENT"),
+		ArchiveCommentRate: getFloatEnvOrDefault("ARCHIVE_COMMENT_RATE", 5),
+
+		FailureLabel:       lookupEnv("FAILURE_LABEL"),
+		RemoveTriggerLabel: getBoolEnvOrDefault("REMOVE_TRIGGER_LABEL_ON_FAILURE", false),
+
+		HistoryFile:    lookupEnv("HISTORY_FILE"),
+		EscalationRuns: getIntEnvOrDefault("ESCALATION_RUNS", 3),
+
+		MetricsFile: lookupEnv("METRICS_FILE"),
+
+		CheckpointFile: lookupEnv("CHECKPOINT_FILE"),
+
+		SkipIneligibleDays: getIntEnvOrDefault("SKIP_INELIGIBLE_DAYS", 30),
+
+		RegressionRuns:   getIntEnvOrDefault("REGRESSION_RUNS", 7),
+		RegressionFactor: getFloatEnvOrDefault("REGRESSION_FACTOR", 3),
+
+		RunPropertyKey: lookupEnv("RUN_PROPERTY_KEY"),
+
+		Locale:      getEnvOrDefault("LOCALE", "en"),
+		TemplateDir: lookupEnv("TEMPLATE_DIR"),
+		ReportFiles: getListEnv("REPORT_FILES"),
+
+		MaxAPICalls: getIntEnvOrDefault("MAX_API_CALLS", 0),
+		MaxRetries:  getIntEnvOrDefault("MAX_RETRIES", 3),
+
+		RetryBaseDelayMS: getIntEnvOrDefault("RETRY_BASE_DELAY_MS", int(jira.DefaultRetryBaseDelay.Milliseconds())),
+		RetryJitter:      getFloatEnvOrDefault("RETRY_JITTER", jira.DefaultRetryJitter),
+
+		MaxRequestsPerSecond: getFloatEnvOrDefault("MAX_REQUESTS_PER_SECOND", 0),
+		JiraRateLimitRPS:     getFloatEnvOrDefault("JIRA_RATE_LIMIT_RPS", 0),
+		SlowRequestMS:        getIntEnvOrDefault("SLOW_REQUEST_MS", 0),
+		SearchHedgeMS:        getIntEnvOrDefault("SEARCH_HEDGE_MS", 0),
+		MetadataCacheDir:     lookupEnv("METADATA_CACHE_DIR"),
+		MetadataTTLSeconds:   getIntEnvOrDefault("METADATA_TTL_SECONDS", int(jira.DefaultMetadataTTL/time.Second)),
+
+		SearchPageSize: getIntEnvOrDefault("SEARCH_PAGE_SIZE", jira.DefaultSearchPageSize),
+		SearchFields:   getListEnv("SEARCH_FIELDS"),
+		SearchExpand:   getListEnv("SEARCH_EXPAND"),
+
+		DryRun: getBoolEnvOrDefault("DRY_RUN", false),
+		Strict: getBoolEnvOrDefault("STRICT_CONFIG", false),
+
+		SupportBundleDir: lookupEnv("SUPPORT_BUNDLE_DIR"),
+
+		WarehouseSink:   lookupEnv("WAREHOUSE_SINK"),
+		WarehouseTable:  lookupEnv("WAREHOUSE_TABLE"),
+		WarehouseURL:    lookupEnv("WAREHOUSE_URL"),
+		WarehouseMethod: getEnvOrDefault("WAREHOUSE_METHOD", "POST"),
+		WarehouseFormat: getEnvOrDefault("WAREHOUSE_FORMAT", "ndjson"),
+		WarehouseToken:  lookupEnv("WAREHOUSE_TOKEN"),
+	}
+
+	config.JiraProjectKey = strings.Join(config.ProjectKeys(), ",")
+	if config.MaxRequestsPerSecond == 0 {
+		config.MaxRequestsPerSecond = config.JiraRateLimitRPS
+	}
+
+	if config.JiraAPIToken == "" && config.JiraAPITokenFile != "" {
+		token, err := ReadTokenFile(config.JiraAPITokenFile)
+		if err != nil {
+			return nil, err
+		}
+		config.JiraAPIToken = token
+	}
+
+	if config.Strict {
+		if err := checkStrict(); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := config.Validate(); err != nil {
@@ -39,33 +371,264 @@ func (c *Config) Validate() error {
 	if c.JiraBaseURL == "" {
 		return fmt.Errorf("JIRA_BASE_URL is required")
 	}
-	if c.JiraEmail == "" {
-		return fmt.Errorf("JIRA_EMAIL is required")
+	switch c.JiraAuthMethod {
+	case jira.AuthBasic:
+		if c.JiraEmail == "" {
+			return fmt.Errorf("JIRA_EMAIL is required")
+		}
+	case jira.AuthOAuth, jira.AuthPAT:
+	default:
+		return fmt.Errorf("JIRA_AUTH_METHOD must be 'basic', 'oauth' or 'pat'")
 	}
 	if c.JiraAPIToken == "" {
-		return fmt.Errorf("JIRA_API_TOKEN is required")
+		return fmt.Errorf("JIRA_API_TOKEN or JIRA_API_TOKEN_FILE is required")
+	}
+	if c.JiraProjectKey == "" && c.JQL == "" {
+		return fmt.Errorf("JIRA_PROJECT_KEY or JIRA_JQL is required")
+	}
+	if c.Selector != "" && c.JQL != "" {
+		return fmt.Errorf("set either SELECTOR or JIRA_JQL, not both")
+	}
+	if jira.HasOrderBy(c.JQL) {
+		return fmt.Errorf("JIRA_JQL must not end in ORDER BY; issues are processed in key order")
 	}
-	if c.JiraProjectKey == "" {
-		return fmt.Errorf("JIRA_PROJECT_KEY is required")
+	if c.ArchiveSkipSecured && len(c.ArchiveSecurityLevels) > 0 {
+		return fmt.Errorf("set either ARCHIVE_SKIP_SECURED or ARCHIVE_SECURITY_LEVELS, not both")
+	}
+	switch c.ServiceDeskPolicy {
+	case "archive", "skip", "resolved":
+	default:
+		return fmt.Errorf("SERVICE_DESK_POLICY must be 'archive', 'skip' or 'resolved'")
+	}
+	if c.ArchiveOlderThanDays < 0 {
+		return fmt.Errorf("ARCHIVE_OLDER_THAN_DAYS must not be negative")
+	}
+	if c.ArchiveResolvedBefore != "" && !validResolvedBefore(c.ArchiveResolvedBefore) {
+		return fmt.Errorf("ARCHIVE_RESOLVED_BEFORE must be a date (2006-01-02) or a number of days such as 90d")
+	}
+	if c.Selector != "" && c.AgeJQL() != "" {
+		return fmt.Errorf("ARCHIVE_OLDER_THAN_DAYS and ARCHIVE_RESOLVED_BEFORE cannot be combined with SELECTOR")
 	}
 	if c.MaxWorkers < 1 {
 		return fmt.Errorf("MAX_WORKERS must be at least 1")
 	}
+	if c.ProgressFD < 0 {
+		return fmt.Errorf("PROGRESS_FD must not be negative")
+	}
+	if c.ProgressFile != "" && c.ProgressFD != 0 {
+		return fmt.Errorf("PROGRESS_FILE and PROGRESS_FD are mutually exclusive")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("LOG_LEVEL must be 'debug', 'info', 'warn' or 'error'")
+	}
+	if c.LogMaxSizeMB < 0 || c.LogMaxAgeDays < 0 || c.LogMaxBackups < 0 {
+		return fmt.Errorf("LOG_MAX_SIZE_MB, LOG_MAX_AGE_DAYS and LOG_MAX_BACKUPS must not be negative")
+	}
+	if c.SyslogTarget != "" && c.SyslogTarget != "syslog" && c.SyslogTarget != "journald" {
+		return fmt.Errorf("SYSLOG_TARGET must be 'syslog' or 'journald'")
+	}
+	if c.AlertFailureRate < 0 || c.AlertFailureRate > 100 {
+		return fmt.Errorf("ALERT_FAILURE_RATE must be between 0 and 100")
+	}
+	if c.AbortFailureRate < 0 || c.AbortFailureRate > 100 {
+		return fmt.Errorf("ABORT_FAILURE_RATE must be between 0 and 100")
+	}
+	if c.AbortMinBatches < 1 {
+		return fmt.Errorf("ABORT_MIN_BATCHES must be at least 1")
+	}
+	if c.CanarySize < 0 || c.CanarySize > jira.MaxArchiveIssues {
+		return fmt.Errorf("CANARY_SIZE must be between 0 and %d, the bulk archive API's limit per request", jira.MaxArchiveIssues)
+	}
+	if c.VerifyConcurrency < 1 {
+		return fmt.Errorf("VERIFY_CONCURRENCY must be at least 1")
+	}
+	if c.ExportFormat != export.FormatJSON && c.ExportFormat != export.FormatTar {
+		return fmt.Errorf("EXPORT_FORMAT must be 'json' or 'tar'")
+	}
+	if c.ExportWorkers < 1 {
+		return fmt.Errorf("EXPORT_WORKERS must be at least 1")
+	}
+	if c.SkipIneligibleDays < 0 {
+		return fmt.Errorf("SKIP_INELIGIBLE_DAYS must not be negative")
+	}
+	if c.MetricsFile != "" && c.HistoryFile == "" {
+		return fmt.Errorf("METRICS_FILE requires HISTORY_FILE")
+	}
+	if c.EscalationRuns < 1 {
+		return fmt.Errorf("ESCALATION_RUNS must be at least 1")
+	}
+	if c.RegressionRuns < 0 {
+		return fmt.Errorf("REGRESSION_RUNS must not be negative")
+	}
+	if c.RegressionFactor <= 1 {
+		return fmt.Errorf("REGRESSION_FACTOR must be greater than 1")
+	}
+	if c.ArchiveCommentRate < 0 {
+		return fmt.Errorf("ARCHIVE_COMMENT_RATE must not be negative")
+	}
+	if c.MaxAPICalls < 0 {
+		return fmt.Errorf("MAX_API_CALLS must not be negative")
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("MAX_RETRIES must not be negative")
+	}
+	if c.RetryBaseDelayMS < 0 {
+		return fmt.Errorf("RETRY_BASE_DELAY_MS must not be negative")
+	}
+	if c.RetryJitter < 0 || c.RetryJitter > 1 {
+		return fmt.Errorf("RETRY_JITTER must be between 0 and 1")
+	}
+	if c.MaxRequestsPerSecond < 0 {
+		return fmt.Errorf("MAX_REQUESTS_PER_SECOND must not be negative")
+	}
+	if c.JiraRateLimitRPS < 0 {
+		return fmt.Errorf("JIRA_RATE_LIMIT_RPS must not be negative")
+	}
+	if c.JiraRateLimitRPS > 0 && c.JiraRateLimitRPS != c.MaxRequestsPerSecond {
+		return fmt.Errorf("JIRA_RATE_LIMIT_RPS is an alias of MAX_REQUESTS_PER_SECOND; set only one of them")
+	}
+	if c.SlowRequestMS < 0 {
+		return fmt.Errorf("SLOW_REQUEST_MS must not be negative")
+	}
+	if c.SearchHedgeMS < 0 {
+		return fmt.Errorf("SEARCH_HEDGE_MS must not be negative")
+	}
+	if c.MetadataTTLSeconds < 0 {
+		return fmt.Errorf("METADATA_TTL_SECONDS must not be negative")
+	}
+	if c.SearchPageSize < 1 || c.SearchPageSize > jira.MaxSearchPageSize {
+		return fmt.Errorf("SEARCH_PAGE_SIZE must be between 1 and %d", jira.MaxSearchPageSize)
+	}
+	for _, path := range c.ReportFiles {
+		if ext := filepath.Ext(path); ext != ".md" && ext != ".html" {
+			return fmt.Errorf("REPORT_FILES entry %s must end in .md or .html", path)
+		}
+	}
+	switch c.WarehouseSink {
+	case "":
+	case "bigquery":
+		if strings.Count(c.WarehouseTable, ".") != 2 {
+			return fmt.Errorf("WAREHOUSE_TABLE must be project.dataset.table for the bigquery sink")
+		}
+	case "http":
+		if c.WarehouseURL == "" {
+			return fmt.Errorf("WAREHOUSE_URL is required for the http sink")
+		}
+	default:
+		return fmt.Errorf("WAREHOUSE_SINK must be 'bigquery' or 'http'")
+	}
+	if c.WarehouseFormat != "ndjson" && c.WarehouseFormat != "csv" {
+		return fmt.Errorf("WAREHOUSE_FORMAT must be 'ndjson' or 'csv'")
+	}
 	return nil
 }
 
+// ProjectKeys returns the projects listed in JIRA_PROJECT_KEY, which may
+// be a comma-separated list
+func (c *Config) ProjectKeys() []string {
+	return jira.ParseProjectKeys(c.JiraProjectKey)
+}
+
+// AgeJQL returns the JQL clauses of the age criteria, empty without any
+func (c *Config) AgeJQL() string {
+	return jira.AgeJQL(c.ArchiveOlderThanDays, c.ArchiveResolvedBefore)
+}
+
+// ExcludeJQL returns the clauses leaving out the EXCLUDE_* issues, or ""
+func (c *Config) ExcludeJQL() string {
+	return jira.ExcludeJQL(c.ExcludeLabels, c.ExcludeStatuses, c.ExcludeIssueTypes)
+}
+
+// NarrowJQL returns the clauses every label or JIRA_JQL search is narrowed
+// by: the age criteria and the exclusions, or "" if there are none
+func (c *Config) NarrowJQL() string {
+	var clauses []string
+	for _, clause := range []string{c.AgeJQL(), c.ExcludeJQL()} {
+		if clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// SearchJQL returns the query selecting issues in projectKey when no
+// SELECTOR is set: JIRA_JQL or the archive label, narrowed by the age
+// criteria and the exclusions
+func (c *Config) SearchJQL(projectKey string) string {
+	jql := c.JQL
+	if jql == "" {
+		jql = jira.LabelJQL(projectKey, c.ArchiveLabel)
+	}
+	return jira.AndJQL(jql, c.NarrowJQL())
+}
+
+// validResolvedBefore accepts a date or a positive number of days ("90d")
+func validResolvedBefore(value string) bool {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return err == nil && n > 0
+	}
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
+
+// ReadTokenFile reads an API token from a file, such as a mounted secret
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read JIRA_API_TOKEN_FILE: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("JIRA_API_TOKEN_FILE %s is empty", path)
+	}
+	return token, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getIntEnvOrDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		invalidEnv(key, value, "an integer")
 	}
 	return defaultValue
 }
+
+func getFloatEnvOrDefault(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		invalidEnv(key, value, "a number")
+	}
+	return defaultValue
+}
+
+func getBoolEnvOrDefault(key string, defaultValue bool) bool {
+	if value := lookupEnv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		invalidEnv(key, value, "a boolean")
+	}
+	return defaultValue
+}
+
+// getListEnv splits a comma-separated variable, dropping empty entries
+func getListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(lookupEnv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// policy holds the settings that decide which issues are archived and how
+// they are protected. Operational settings such as logging and alerting are
+// deliberately left out so changing them does not count as policy drift.
+type policy struct {
+	Selection            string  `json:"selection"`
+	FreezeDates          string  `json:"freezeDates"`
+	FreezeCalendarURL    string  `json:"freezeCalendarUrl"`
+	AbortFailureRate     float64 `json:"abortFailureRate"`
+	AbortMinBatches      int     `json:"abortMinBatches"`
+	CanarySize           int     `json:"canarySize"`
+	EligibilityPreflight bool    `json:"eligibilityPreflight"`
+	PartitionByProject   bool    `json:"partitionByProject"`
+	ArchiveComment       string  `json:"archiveComment"`
+	FailureLabel         string  `json:"failureLabel"`
+	RemoveTriggerLabel   bool    `json:"removeTriggerLabel"`
+	// Settings added later are omitted when unset, so that existing
+	// policies keep their hash
+	SkipSecured    bool     `json:"skipSecured,omitempty"`
+	SecurityLevels []string `json:"securityLevels,omitempty"`
+	ServiceDesk    string   `json:"serviceDesk,omitempty"`
+	ExcludeLabels  []string `json:"excludeLabels,omitempty"`
+	ExcludeStatus  []string `json:"excludeStatuses,omitempty"`
+	ExcludeTypes   []string `json:"excludeIssueTypes,omitempty"`
+}
+
+// PolicyHash returns a SHA-256 fingerprint of the effective archive policy.
+// selection is the resolved issue selection (the selector's name), which
+// includes the JQL read from jqlfile selectors.
+func (c *Config) PolicyHash(selection string) string {
+	p := policy{
+		Selection:            selection,
+		FreezeDates:          c.FreezeDates,
+		FreezeCalendarURL:    c.FreezeCalendarURL,
+		AbortFailureRate:     c.AbortFailureRate,
+		AbortMinBatches:      c.AbortMinBatches,
+		CanarySize:           c.CanarySize,
+		EligibilityPreflight: c.EligibilityPreflight,
+		PartitionByProject:   c.PartitionByProject,
+		ArchiveComment:       c.ArchiveComment,
+		FailureLabel:         c.FailureLabel,
+		RemoveTriggerLabel:   c.RemoveTriggerLabel,
+		SkipSecured:          c.ArchiveSkipSecured,
+		SecurityLevels:       c.ArchiveSecurityLevels,
+		ExcludeLabels:        c.ExcludeLabels,
+		ExcludeStatus:        c.ExcludeStatuses,
+		ExcludeTypes:         c.ExcludeIssueTypes,
+	}
+	if c.ServiceDeskPolicy != "archive" {
+		p.ServiceDesk = c.ServiceDeskPolicy
+	}
+
+	// Struct fields marshal in declaration order, so the encoding is stable
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/suggest"
+)
+
+// strictPrefixes are the prefixes of this tool's variables; in strict mode
+// any other variable starting with one of them is rejected
+var strictPrefixes = []string{"JIRA_", "ARCHIVE_"}
+
+// envReads records the variables read by Load and the values it could not
+// parse and replaced with defaults
+var envReads struct {
+	mu      sync.Mutex
+	known   map[string]bool
+	invalid []string
+}
+
+// lookupEnv returns the variable's value and records it as known
+func lookupEnv(key string) string {
+	envReads.mu.Lock()
+	defer envReads.mu.Unlock()
+	if envReads.known == nil {
+		envReads.known = make(map[string]bool)
+	}
+	envReads.known[key] = true
+	return os.Getenv(key)
+}
+
+// invalidEnv records a value that did not parse as kind
+func invalidEnv(key, value, kind string) {
+	envReads.mu.Lock()
+	defer envReads.mu.Unlock()
+	envReads.invalid = append(envReads.invalid, fmt.Sprintf("%s=%q is not %s", key, value, kind))
+}
+
+// resetEnvReads clears the invalid values recorded by a previous Load
+func resetEnvReads() {
+	envReads.mu.Lock()
+	defer envReads.mu.Unlock()
+	envReads.invalid = nil
+}
+
+// checkStrict rejects, in STRICT_CONFIG mode, values that would otherwise
+// silently fall back to defaults: variables that look like misspellings of
+// known ones (JIRA_*, ARCHIVE_* or a near match such as ARCHVE_LABEL) and
+// values that do not parse
+func checkStrict() error {
+	envReads.mu.Lock()
+	defer envReads.mu.Unlock()
+
+	known := make([]string, 0, len(envReads.known))
+	for key := range envReads.known {
+		known = append(known, key)
+	}
+	sort.Strings(known)
+
+	var problems []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if envReads.known[name] {
+			continue
+		}
+		matches := suggest.Closest(name, known, 1)
+		switch {
+		case len(matches) > 0:
+			problems = append(problems, fmt.Sprintf("unknown variable %s (did you mean %s?)", name, matches[0]))
+		case hasStrictPrefix(name):
+			problems = append(problems, fmt.Sprintf("unknown variable %s", name))
+		}
+	}
+	sort.Strings(problems)
+	problems = append(problems, envReads.invalid...)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("STRICT_CONFIG: " + strings.Join(problems, "; "))
+}
+
+func hasStrictPrefix(name string) bool {
+	for _, prefix := range strictPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
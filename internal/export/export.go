@@ -0,0 +1,118 @@
+// Package export stores the full JSON of issues before they are archived,
+// as an offline record of what a run archived: one file per issue in a
+// directory per run, or one gzipped tarball per run.
+package export
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Export formats selected by EXPORT_FORMAT
+const (
+	FormatJSON = "json"
+	FormatTar  = "tar"
+)
+
+// Writer stores exported issues. It is safe for concurrent use.
+type Writer interface {
+	// Write stores the JSON of the issue with the given key
+	Write(key string, data []byte) error
+	// Close finishes the export; a tarball is incomplete until it is closed
+	Close() error
+	// Path is the directory or tarball written to
+	Path() string
+}
+
+// Open starts the export of run runID in dir: dir/RUN-ID/KEY.json for
+// FormatJSON, dir/RUN-ID.tar.gz holding RUN-ID/KEY.json for FormatTar
+func Open(dir, format, runID string) (Writer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatJSON:
+		path := filepath.Join(dir, runID)
+		if err := os.Mkdir(path, 0o755); err != nil {
+			return nil, err
+		}
+		return &dirWriter{path: path}, nil
+	case FormatTar:
+		path := filepath.Join(dir, runID+".tar.gz")
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		gz := gzip.NewWriter(f)
+		return &tarWriter{path: path, runID: runID, file: f, gzip: gz, tar: tar.NewWriter(gz)}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// dirWriter writes one file per issue
+type dirWriter struct {
+	path string
+}
+
+func (w *dirWriter) Write(key string, data []byte) error {
+	return os.WriteFile(filepath.Join(w.path, key+".json"), data, 0o644)
+}
+
+func (w *dirWriter) Close() error {
+	return nil
+}
+
+func (w *dirWriter) Path() string {
+	return w.path
+}
+
+// tarWriter appends every issue to a gzipped tarball
+type tarWriter struct {
+	path  string
+	runID string
+
+	mu   sync.Mutex
+	file *os.File
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+func (w *tarWriter) Write(key string, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	header := &tar.Header{
+		Name:    w.runID + "/" + key + ".json",
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := w.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.tar.Write(data)
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.tar.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.gzip.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *tarWriter) Path() string {
+	return w.path
+}
@@ -0,0 +1,405 @@
+// Package fakejira is an in-memory stand-in for the Jira Cloud endpoints the
+// archiver uses. It is meant for benchmarks and local experiments, not for
+// checking Jira semantics: apart from "key in (...)", JQL is not evaluated
+// and every search returns all issues that are not archived yet.
+package fakejira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Server serves a fixed set of issues over HTTP
+type Server struct {
+	*httptest.Server
+
+	latency time.Duration
+
+	mu         sync.Mutex
+	issues     []*jira.Issue
+	byKey      map[string]*jira.Issue
+	properties map[string]map[string]json.RawMessage
+	requests   int
+}
+
+// New starts a server holding issues. Every request waits latency before
+// it is answered to approximate a round trip to Jira Cloud.
+func New(issues []jira.Issue, latency time.Duration) *Server {
+	s := &Server{
+		latency:    latency,
+		byKey:      make(map[string]*jira.Issue, len(issues)),
+		properties: make(map[string]map[string]json.RawMessage),
+	}
+	for i := range issues {
+		issue := issues[i]
+		s.issues = append(s.issues, &issue)
+		s.byKey[issue.Key] = &issue
+		s.byKey[issue.ID] = &issue
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/3/search/jql", s.search)
+	mux.HandleFunc("PUT /rest/api/3/issue/archive", s.bulkArchive(true))
+	mux.HandleFunc("PUT /rest/api/3/issue/unarchive", s.bulkArchive(false))
+	mux.HandleFunc("GET /rest/api/3/issue/{key}", s.getIssue)
+	mux.HandleFunc("PUT /rest/api/3/issue/{key}", s.editIssue)
+	mux.HandleFunc("POST /rest/api/3/issue/{key}/comment", s.addComment)
+	mux.HandleFunc("PUT /rest/api/3/issue/{key}/properties/{property}", s.setProperty)
+	mux.HandleFunc("GET /rest/api/3/issue/{key}/properties/{property}", s.getProperty)
+	mux.HandleFunc("GET /rest/api/3/mypermissions", s.myPermissions)
+	mux.HandleFunc("GET /rest/api/3/auditing/record", s.auditRecords)
+	mux.HandleFunc("GET /rest/api/3/label", s.labels)
+	mux.HandleFunc("GET /rest/api/3/project/search", s.projects)
+
+	s.Server = httptest.NewServer(s.delay(mux))
+	return s
+}
+
+// Generate builds n synthetic issues in project, all carrying label
+func Generate(n int, project, label string) []jira.Issue {
+	issues := make([]jira.Issue, n)
+	for i := range issues {
+		issues[i] = jira.Issue{
+			ID:  strconv.Itoa(10000 + i),
+			Key: fmt.Sprintf("%s-%d", project, i+1),
+			Fields: jira.IssueFields{
+				Summary:   fmt.Sprintf("Synthetic issue %d", i+1),
+				Project:   &jira.Project{Key: project, Name: project},
+				IssueType: &jira.IssueType{Name: "Task"},
+				Labels:    []string{label},
+				Status:    &jira.Status{Name: "Done"},
+				Updated:   "2024-01-01T00:00:00.000+0000",
+			},
+		}
+	}
+	return issues
+}
+
+// Requests returns the number of requests served
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Archived returns the number of archived issues
+func (s *Server) Archived() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	archived := 0
+	for _, issue := range s.issues {
+		if issue.Fields.ArchivedDate != "" {
+			archived++
+		}
+	}
+	return archived
+}
+
+// Move changes an issue's key, as moving it to another project would. The
+// issue stays reachable by its ID.
+func (s *Server) Move(key, newKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.byKey[key]
+	if !ok {
+		return
+	}
+	delete(s.byKey, key)
+	issue.Key = newKey
+	if project, _, ok := strings.Cut(newKey, "-"); ok {
+		issue.Fields.Project = &jira.Project{Key: project, Name: project}
+	}
+	s.byKey[newKey] = issue
+}
+
+func (s *Server) delay(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		s.mu.Unlock()
+
+		if s.latency > 0 {
+			time.Sleep(s.latency)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("nextPageToken"))
+	maxResults, err := strconv.Atoi(r.URL.Query().Get("maxResults"))
+	if err != nil || maxResults <= 0 {
+		maxResults = 50
+	}
+
+	refs, byRef := keyInRefs(r.URL.Query().Get("jql"))
+
+	s.mu.Lock()
+	var open []jira.Issue
+	for _, issue := range s.issues {
+		if byRef && !refs[issue.ID] && !refs[issue.Key] {
+			continue
+		}
+		if issue.Fields.ArchivedDate == "" {
+			open = append(open, *issue)
+		}
+	}
+	s.mu.Unlock()
+
+	result := jira.SearchResult{Total: len(open)}
+	if offset < len(open) {
+		end := min(offset+maxResults, len(open))
+		result.Issues = open[offset:end]
+		if end < len(open) {
+			result.NextPageToken = strconv.Itoa(end)
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// keyInRefs parses a "key in (...)" query into the listed IDs and keys
+func keyInRefs(jql string) (map[string]bool, bool) {
+	list, ok := strings.CutPrefix(jql, "key in (")
+	if !ok || !strings.HasSuffix(list, ")") {
+		return nil, false
+	}
+	refs := make(map[string]bool)
+	for _, ref := range strings.Split(strings.TrimSuffix(list, ")"), ",") {
+		refs[strings.TrimSpace(ref)] = true
+	}
+	return refs, true
+}
+
+// bulkArchive handles the archive (archive true) and unarchive endpoints
+func (s *Server) bulkArchive(archive bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req jira.ArchiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.IssueIdsOrKeys) > jira.MaxArchiveIssues {
+			http.Error(w, fmt.Sprintf("at most %d issues can be sent per request", jira.MaxArchiveIssues), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		resp := jira.ArchiveResponse{}
+		var notFound []string
+		for _, idOrKey := range req.IssueIdsOrKeys {
+			issue, ok := s.byKey[idOrKey]
+			if !ok {
+				notFound = append(notFound, idOrKey)
+				continue
+			}
+			switch {
+			case archive && issue.Fields.ArchivedDate == "":
+				issue.Fields.ArchivedDate = time.Now().UTC().Format("2006-01-02T15:04:05.000-0700")
+				resp.NumberOfIssuesUpdated++
+			case !archive && issue.Fields.ArchivedDate != "":
+				issue.Fields.ArchivedDate = ""
+				resp.NumberOfIssuesUpdated++
+			}
+		}
+		if len(notFound) > 0 {
+			resp.Errors = map[string]jira.ArchiveError{
+				jira.ArchiveErrorIssuesNotFound: {
+					Count:          len(notFound),
+					IssueIdsOrKeys: notFound,
+					Message:        "Issue does not exist or you do not have permission to see it.",
+				},
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	issue, ok := s.byKey[r.PathValue("key")]
+	var copied jira.Issue
+	if ok {
+		copied = *issue
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "issue does not exist", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, copied)
+}
+
+func (s *Server) editIssue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Update struct {
+			Labels []map[string]string `json:"labels"`
+		} `json:"update"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.byKey[r.PathValue("key")]
+	if !ok {
+		http.Error(w, "issue does not exist", http.StatusNotFound)
+		return
+	}
+	for _, op := range req.Update.Labels {
+		if label, ok := op["add"]; ok {
+			issue.Fields.Labels = append(issue.Fields.Labels, label)
+		}
+		if label, ok := op["remove"]; ok {
+			kept := issue.Fields.Labels[:0]
+			for _, l := range issue.Fields.Labels {
+				if l != label {
+					kept = append(kept, l)
+				}
+			}
+			issue.Fields.Labels = kept
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addComment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, ok := s.byKey[r.PathValue("key")]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "issue does not exist", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": "1"})
+}
+
+func (s *Server) setProperty(w http.ResponseWriter, r *http.Request) {
+	var value json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue, ok := s.byKey[r.PathValue("key")]
+	if !ok {
+		http.Error(w, "issue does not exist", http.StatusNotFound)
+		return
+	}
+	props := s.properties[issue.Key]
+	if props == nil {
+		props = make(map[string]json.RawMessage)
+		s.properties[issue.Key] = props
+	}
+	status := http.StatusCreated
+	if _, exists := props[r.PathValue("property")]; exists {
+		status = http.StatusOK
+	}
+	props[r.PathValue("property")] = value
+	w.WriteHeader(status)
+}
+
+func (s *Server) getProperty(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var value json.RawMessage
+	issue, ok := s.byKey[r.PathValue("key")]
+	if ok {
+		value, ok = s.properties[issue.Key][r.PathValue("property")]
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "property does not exist", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"key": r.PathValue("property"), "value": value})
+}
+
+// myPermissions grants every requested permission
+func (s *Server) myPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions := make(map[string]map[string]bool)
+	for _, key := range strings.Split(r.URL.Query().Get("permissions"), ",") {
+		if key != "" {
+			permissions[key] = map[string]bool{"havePermission": true}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"permissions": permissions})
+}
+
+// auditRecords returns an empty audit log
+func (s *Server) auditRecords(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"offset": 0, "limit": 1000, "total": 0, "records": []any{}})
+}
+
+// labels lists the labels of all issues, archived or not, in one page
+func (s *Server) labels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	seen := make(map[string]bool)
+	values := []string{}
+	for _, issue := range s.issues {
+		for _, label := range issue.Fields.Labels {
+			if !seen[label] {
+				seen[label] = true
+				values = append(values, label)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(values)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"startAt":    0,
+		"maxResults": len(values),
+		"total":      len(values),
+		"isLast":     true,
+		"values":     values,
+	})
+}
+
+// projects lists the projects of all issues in one page
+func (s *Server) projects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	seen := make(map[string]bool)
+	values := []jira.Project{}
+	for _, issue := range s.issues {
+		if p := issue.Fields.Project; p != nil && !seen[p.Key] {
+			seen[p.Key] = true
+			values = append(values, *p)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	writeJSON(w, http.StatusOK, map[string]any{
+		"startAt":    0,
+		"maxResults": len(values),
+		"total":      len(values),
+		"isLast":     true,
+		"values":     values,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
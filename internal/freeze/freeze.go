@@ -0,0 +1,375 @@
+package freeze
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+const dateLayout = "2006-01-02"
+
+// Window represents a period during which archiving is not allowed
+type Window struct {
+	Start   time.Time
+	End     time.Time
+	Summary string
+}
+
+// Contains reports whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// String returns a human readable description of the window
+func (w Window) String() string {
+	if w.Summary != "" {
+		return fmt.Sprintf("%s (%s - %s)", w.Summary, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s - %s", w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+}
+
+// Calendar is a set of freeze windows
+type Calendar struct {
+	Windows []Window
+}
+
+// Active returns the first window containing t, if any
+func (c *Calendar) Active(t time.Time) (Window, bool) {
+	for _, w := range c.Windows {
+		if w.Contains(t) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Load builds a calendar from a static date list and an optional iCal URL
+func Load(dates, icalURL string) (*Calendar, error) {
+	calendar := &Calendar{}
+
+	if dates != "" {
+		windows, err := ParseDates(dates)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Windows = append(calendar.Windows, windows...)
+	}
+
+	if icalURL != "" {
+		windows, err := fetchICal(icalURL)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Windows = append(calendar.Windows, windows...)
+	}
+
+	return calendar, nil
+}
+
+// ParseDates parses a comma-separated list of dates (2024-12-24) or
+// inclusive date ranges (2024-12-24..2025-01-05) in local time
+func ParseDates(value string) ([]Window, error) {
+	var windows []Window
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(entry, "..")
+		if !isRange {
+			to = from
+		}
+
+		start, err := time.ParseInLocation(dateLayout, strings.TrimSpace(from), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid freeze date %q: %w", entry, err)
+		}
+		end, err := time.ParseInLocation(dateLayout, strings.TrimSpace(to), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid freeze date %q: %w", entry, err)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("invalid freeze range %q: end is before start", entry)
+		}
+
+		windows = append(windows, Window{
+			Start: start,
+			End:   end.AddDate(0, 0, 1),
+		})
+	}
+	return windows, nil
+}
+
+// fetchICal downloads an iCal feed and extracts its events as windows
+func fetchICal(icalURL string) ([]Window, error) {
+	client := transport.Client(30 * time.Second)
+
+	resp, err := client.Get(icalURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch freeze calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("freeze calendar returned status %d", resp.StatusCode)
+	}
+
+	windows, err := ParseICal(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse freeze calendar: %w", err)
+	}
+	return windows, nil
+}
+
+// ParseICal extracts the VEVENT windows of an iCalendar stream. An event
+// lasts until DTEND or for its DURATION, and repeats by its RRULE and RDATE
+// values except on EXDATE. Recurrences without an end are expanded two
+// years ahead. RRULE parts other than FREQ, INTERVAL, COUNT, UNTIL and
+// BYDAY are an error, so that no freeze is silently shortened.
+func ParseICal(r io.Reader) ([]Window, error) {
+	return parseICal(r, time.Now().Add(recurrenceHorizon))
+}
+
+// event is a VEVENT being parsed
+type event struct {
+	summary  string
+	start    time.Time
+	allDay   bool
+	end      time.Time
+	duration *icalDuration
+	rule     string
+	rdates   []Window // End is zero unless the RDATE is a period
+	exdates  []time.Time
+}
+
+// parseICal is ParseICal, expanding open-ended recurrences up to horizon
+func parseICal(r io.Reader, horizon time.Time) ([]Window, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []Window
+	var current *event
+
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		prop, params, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				current = &event{}
+			}
+		case "END":
+			if strings.EqualFold(value, "VEVENT") && current != nil {
+				occurrences, err := current.windows(horizon)
+				if err != nil {
+					return nil, fmt.Errorf("event %q: %w", current.summary, err)
+				}
+				windows = append(windows, occurrences...)
+				current = nil
+			}
+		case "DTSTART":
+			if current != nil {
+				t, dateOnly, err := parseICalTime(params, value)
+				if err != nil {
+					return nil, err
+				}
+				current.start = t
+				current.allDay = dateOnly
+			}
+		case "DTEND":
+			if current != nil {
+				t, _, err := parseICalTime(params, value)
+				if err != nil {
+					return nil, err
+				}
+				current.end = t
+			}
+		case "DURATION":
+			if current != nil {
+				d, err := parseDuration(value)
+				if err != nil {
+					return nil, err
+				}
+				current.duration = &d
+			}
+		case "RRULE":
+			if current != nil {
+				current.rule = value
+			}
+		case "RDATE":
+			if current != nil {
+				for _, v := range strings.Split(value, ",") {
+					w, err := parsePeriod(params, v)
+					if err != nil {
+						return nil, err
+					}
+					current.rdates = append(current.rdates, w)
+				}
+			}
+		case "EXDATE":
+			if current != nil {
+				for _, v := range strings.Split(value, ",") {
+					t, _, err := parseICalTime(params, v)
+					if err != nil {
+						return nil, err
+					}
+					current.exdates = append(current.exdates, t)
+				}
+			}
+		case "SUMMARY":
+			if current != nil {
+				current.summary = value
+			}
+		}
+	}
+
+	return windows, nil
+}
+
+// windows returns every occurrence of the event that is not excluded
+func (e *event) windows(horizon time.Time) ([]Window, error) {
+	if e.start.IsZero() {
+		return nil, fmt.Errorf("no DTSTART")
+	}
+	if !e.end.IsZero() && e.duration != nil {
+		return nil, fmt.Errorf("both DTEND and DURATION are set")
+	}
+
+	starts := []time.Time{e.start}
+	if e.rule != "" {
+		r, err := parseRule(e.rule)
+		if err != nil {
+			return nil, err
+		}
+		starts = r.starts(e.start, horizon)
+	}
+
+	var windows []Window
+	seen := make(map[time.Time]bool)
+	add := func(start, end time.Time) {
+		if seen[start] || slices.ContainsFunc(e.exdates, start.Equal) {
+			return
+		}
+		seen[start] = true
+		windows = append(windows, Window{Start: start, End: end, Summary: e.summary})
+	}
+	for _, start := range starts {
+		add(start, e.endOf(start))
+	}
+	for _, rdate := range e.rdates {
+		end := rdate.End
+		if end.IsZero() {
+			end = e.endOf(rdate.Start)
+		}
+		add(rdate.Start, end)
+	}
+	return windows, nil
+}
+
+// endOf returns the end of the occurrence starting at start
+func (e *event) endOf(start time.Time) time.Time {
+	switch {
+	case e.duration != nil:
+		return e.duration.after(start)
+	case !e.end.IsZero() && e.allDay:
+		days := int(math.Round(e.end.Sub(e.start).Hours() / 24))
+		return start.AddDate(0, 0, days)
+	case !e.end.IsZero():
+		return start.Add(e.end.Sub(e.start))
+	case e.allDay:
+		// RFC 5545: an all-day event without DTEND lasts one day
+		return start.AddDate(0, 0, 1)
+	default:
+		return start
+	}
+}
+
+// parsePeriod parses an RDATE value: a date, a date-time, or a period of a
+// start and either an end or a duration
+func parsePeriod(params, value string) (Window, error) {
+	from, to, isPeriod := strings.Cut(value, "/")
+	start, _, err := parseICalTime(params, from)
+	if err != nil {
+		return Window{}, err
+	}
+	if !isPeriod {
+		return Window{Start: start}, nil
+	}
+	if strings.HasPrefix(to, "P") || strings.HasPrefix(to, "+P") {
+		d, err := parseDuration(to)
+		if err != nil {
+			return Window{}, err
+		}
+		return Window{Start: start, End: d.after(start)}, nil
+	}
+	end, _, err := parseICalTime(params, to)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Start: start, End: end}, nil
+}
+
+// unfoldLines joins folded iCal content lines (continuations start with whitespace)
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICalTime parses DATE and DATE-TIME values, honoring TZID parameters
+func parseICalTime(params, value string) (time.Time, bool, error) {
+	loc := time.Local
+	for _, param := range strings.Split(params, ";") {
+		if key, tzid, ok := strings.Cut(param, "="); ok && strings.EqualFold(key, "TZID") {
+			l, err := time.LoadLocation(tzid)
+			if err != nil {
+				return time.Time{}, false, fmt.Errorf("unknown TZID %q: %w", tzid, err)
+			}
+			loc = l
+		}
+	}
+
+	if len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q: %w", value, err)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q: %w", value, err)
+		}
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q: %w", value, err)
+	}
+	return t, false, nil
+}
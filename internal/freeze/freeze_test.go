@@ -0,0 +1,212 @@
+package freeze
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// horizon bounds open-ended recurrences in the tests
+var horizon = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func parse(t *testing.T, events ...string) []Window {
+	t.Helper()
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"
+	for _, e := range events {
+		ics += "BEGIN:VEVENT\r\n" + strings.ReplaceAll(strings.TrimSpace(e), "\n", "\r\n") + "\r\nEND:VEVENT\r\n"
+	}
+	ics += "END:VCALENDAR\r\n"
+
+	windows, err := parseICal(strings.NewReader(ics), horizon)
+	if err != nil {
+		t.Fatalf("parseICal() error = %v", err)
+	}
+	return windows
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse("20060102T150405Z", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func checkWindows(t *testing.T, got []Window, want ...[2]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d windows %v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		if !got[i].Start.Equal(utc(w[0])) || !got[i].End.Equal(utc(w[1])) {
+			t.Errorf("window %d = %s - %s, want %s - %s", i, got[i].Start, got[i].End, w[0], w[1])
+		}
+	}
+}
+
+func TestParseICalSingleEvent(t *testing.T) {
+	windows := parse(t, `
+SUMMARY:Release freeze
+DTSTART:20241220T090000Z
+DTEND:20241222T180000Z`)
+	checkWindows(t, windows, [2]string{"20241220T090000Z", "20241222T180000Z"})
+	if windows[0].Summary != "Release freeze" {
+		t.Errorf("Summary = %q, want %q", windows[0].Summary, "Release freeze")
+	}
+}
+
+func TestParseICalFoldedSummary(t *testing.T) {
+	windows := parse(t, "SUMMARY:Year-end\n  audit\nDTSTART:20241230T000000Z\nDTEND:20241231T000000Z")
+	if windows[0].Summary != "Year-end audit" {
+		t.Errorf("Summary = %q, want %q", windows[0].Summary, "Year-end audit")
+	}
+}
+
+func TestParseICalDuration(t *testing.T) {
+	windows := parse(t, `
+DTSTART:20241220T090000Z
+DURATION:P1DT4H30M`)
+	checkWindows(t, windows, [2]string{"20241220T090000Z", "20241221T133000Z"})
+
+	weeks := parse(t, `
+DTSTART:20241220T000000Z
+DURATION:P2W`)
+	checkWindows(t, weeks, [2]string{"20241220T000000Z", "20250103T000000Z"})
+}
+
+func TestParseICalAllDay(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	windows := parse(t, `DTSTART;VALUE=DATE;TZID=Asia/Tokyo:20241224`)
+	if len(windows) != 1 {
+		t.Fatalf("got %d windows, want 1", len(windows))
+	}
+	start := time.Date(2024, 12, 24, 0, 0, 0, 0, loc)
+	if !windows[0].Start.Equal(start) || !windows[0].End.Equal(start.AddDate(0, 0, 1)) {
+		t.Errorf("window = %s, want the whole of 2024-12-24 in Tokyo", windows[0])
+	}
+}
+
+func TestParseICalWeeklyRule(t *testing.T) {
+	// Fridays and Mondays, four occurrences, starting on a Friday
+	windows := parse(t, `
+DTSTART:20241220T090000Z
+DTEND:20241220T170000Z
+RRULE:FREQ=WEEKLY;BYDAY=MO,FR;COUNT=4`)
+	checkWindows(t, windows,
+		[2]string{"20241220T090000Z", "20241220T170000Z"},
+		[2]string{"20241223T090000Z", "20241223T170000Z"},
+		[2]string{"20241227T090000Z", "20241227T170000Z"},
+		[2]string{"20241230T090000Z", "20241230T170000Z"},
+	)
+}
+
+func TestParseICalRuleUntilAndExdate(t *testing.T) {
+	windows := parse(t, `
+DTSTART:20241202T000000Z
+DURATION:PT12H
+RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20250113T000000Z
+EXDATE:20241216T000000Z`)
+	checkWindows(t, windows,
+		[2]string{"20241202T000000Z", "20241202T120000Z"},
+		[2]string{"20241230T000000Z", "20241230T120000Z"},
+		[2]string{"20250113T000000Z", "20250113T120000Z"},
+	)
+}
+
+func TestParseICalOpenEndedRuleStopsAtHorizon(t *testing.T) {
+	windows := parse(t, `
+DTSTART:20250101T000000Z
+DTEND:20250101T010000Z
+RRULE:FREQ=DAILY`)
+	// January and February 2025
+	if len(windows) != 59 {
+		t.Fatalf("got %d windows, want 59", len(windows))
+	}
+	if last := windows[len(windows)-1]; !last.Start.Equal(utc("20250228T000000Z")) {
+		t.Errorf("last window starts %s, want 2025-02-28", last.Start)
+	}
+}
+
+func TestParseICalMonthlySkipsShortMonths(t *testing.T) {
+	windows := parse(t, `
+DTSTART:20241031T000000Z
+DTEND:20241031T060000Z
+RRULE:FREQ=MONTHLY;COUNT=3`)
+	checkWindows(t, windows,
+		[2]string{"20241031T000000Z", "20241031T060000Z"},
+		[2]string{"20241231T000000Z", "20241231T060000Z"},
+		[2]string{"20250131T000000Z", "20250131T060000Z"},
+	)
+}
+
+func TestParseICalRdate(t *testing.T) {
+	windows := parse(t, `
+DTSTART:20241220T090000Z
+DURATION:PT1H
+RDATE:20241227T090000Z,20250103T090000Z/PT3H`)
+	checkWindows(t, windows,
+		[2]string{"20241220T090000Z", "20241220T100000Z"},
+		[2]string{"20241227T090000Z", "20241227T100000Z"},
+		[2]string{"20250103T090000Z", "20250103T120000Z"},
+	)
+}
+
+func TestParseICalErrors(t *testing.T) {
+	tests := map[string]string{
+		"unsupported rule part": "DTSTART:20241220T090000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=-1",
+		"unsupported frequency": "DTSTART:20241220T090000Z\nRRULE:FREQ=HOURLY",
+		"BYDAY ordinal":         "DTSTART:20241220T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=1FR",
+		"DTEND and DURATION":    "DTSTART:20241220T090000Z\nDTEND:20241220T100000Z\nDURATION:PT1H",
+		"invalid duration":      "DTSTART:20241220T090000Z\nDURATION:-PT1H",
+		"no DTSTART":            "SUMMARY:Freeze\nDTEND:20241220T100000Z",
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			ics := "BEGIN:VEVENT\r\n" + strings.ReplaceAll(body, "\n", "\r\n") + "\r\nEND:VEVENT\r\n"
+			if windows, err := parseICal(strings.NewReader(ics), horizon); err == nil {
+				t.Errorf("parseICal() = %v, want an error", windows)
+			}
+		})
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	windows, err := ParseDates("2024-12-24, 2024-12-28..2025-01-05")
+	if err != nil {
+		t.Fatalf("ParseDates() error = %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("got %d windows, want 2", len(windows))
+	}
+	end := time.Date(2025, 1, 6, 0, 0, 0, 0, time.Local)
+	if !windows[1].End.Equal(end) {
+		t.Errorf("range ends %s, want %s (the end date is included)", windows[1].End, end)
+	}
+
+	for _, bad := range []string{"2024-13-01", "2025-01-05..2024-12-28"} {
+		if _, err := ParseDates(bad); err == nil {
+			t.Errorf("ParseDates(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestCalendarActive(t *testing.T) {
+	calendar := &Calendar{Windows: parse(t, `
+SUMMARY:Weekly change freeze
+DTSTART:20241220T090000Z
+DURATION:PT8H
+RRULE:FREQ=WEEKLY`)}
+
+	if w, ok := calendar.Active(utc("20250110T120000Z")); !ok || w.Summary != "Weekly change freeze" {
+		t.Errorf("Active(a later Friday) = %v, %v; want the weekly freeze", w, ok)
+	}
+	if _, ok := calendar.Active(utc("20250110T170000Z")); ok {
+		t.Error("Active(after the window ends) = true, want false")
+	}
+	if _, ok := calendar.Active(utc("20250109T120000Z")); ok {
+		t.Error("Active(a Thursday) = true, want false")
+	}
+}
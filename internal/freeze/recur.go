@@ -0,0 +1,231 @@
+package freeze
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of a single recurring event
+const maxOccurrences = 100000
+
+// recurrenceHorizon is how far past the current time recurrences without
+// COUNT or UNTIL are expanded
+const recurrenceHorizon = 2 * 365 * 24 * time.Hour
+
+// icalDuration is a DURATION value. Days are kept apart from the clock time
+// so that a day stays a calendar day across daylight saving changes.
+type icalDuration struct {
+	days  int
+	clock time.Duration
+}
+
+// after returns t moved forward by the duration
+func (d icalDuration) after(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.clock)
+}
+
+// parseDuration parses an RFC 5545 duration such as P1D, PT4H30M or P2W.
+// Negative durations are rejected, as they make no sense for an event.
+func parseDuration(value string) (icalDuration, error) {
+	var d icalDuration
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(value, "+"), "P")
+	if !ok || rest == "" {
+		return d, fmt.Errorf("invalid duration %q", value)
+	}
+
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return d, fmt.Errorf("invalid duration %q", value)
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		switch unit := rest[i]; {
+		case unit == 'W' && !inTime:
+			d.days += 7 * n
+		case unit == 'D' && !inTime:
+			d.days += n
+		case unit == 'H' && inTime:
+			d.clock += time.Duration(n) * time.Hour
+		case unit == 'M' && inTime:
+			d.clock += time.Duration(n) * time.Minute
+		case unit == 'S' && inTime:
+			d.clock += time.Duration(n) * time.Second
+		default:
+			return d, fmt.Errorf("invalid duration %q", value)
+		}
+		rest = rest[i+1:]
+	}
+	return d, nil
+}
+
+// rule is the supported subset of an RRULE: a daily, weekly, monthly or
+// yearly frequency with INTERVAL, COUNT, UNTIL and, for daily and weekly
+// rules, BYDAY weekdays
+type rule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time // exclusive; zero means no end
+	byDay    []time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRule parses an RRULE value. Parts outside the supported subset are
+// an error rather than being ignored: a freeze that repeats differently
+// than the calendar says would let runs go ahead during it.
+func parseRule(value string) (*rule, error) {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+			switch r.freq {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+			default:
+				return nil, fmt.Errorf("RRULE frequency %s is not supported", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE interval %q", val)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE count %q", val)
+			}
+			r.count = n
+		case "UNTIL":
+			t, dateOnly, err := parseICalTime("", val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RRULE until: %w", err)
+			}
+			// UNTIL is inclusive; a date includes the whole day
+			if dateOnly {
+				r.until = t.AddDate(0, 0, 1)
+			} else {
+				r.until = t.Add(time.Second)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("RRULE BYDAY value %q is not supported", day)
+				}
+				r.byDay = append(r.byDay, weekday)
+			}
+		case "WKST":
+			if !strings.EqualFold(val, "MO") {
+				return nil, fmt.Errorf("RRULE WKST=%s is not supported", val)
+			}
+		default:
+			return nil, fmt.Errorf("RRULE part %s is not supported", key)
+		}
+	}
+	if r.freq == "" {
+		return nil, fmt.Errorf("RRULE %q has no FREQ", value)
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, fmt.Errorf("RRULE %q sets both COUNT and UNTIL", value)
+	}
+	if len(r.byDay) > 0 && r.freq != "DAILY" && r.freq != "WEEKLY" {
+		return nil, fmt.Errorf("RRULE BYDAY is only supported with FREQ=DAILY or FREQ=WEEKLY")
+	}
+	return r, nil
+}
+
+// starts returns the start times the rule generates from start, up to but
+// excluding horizon unless COUNT or UNTIL end the rule earlier
+func (r *rule) starts(start, horizon time.Time) []time.Time {
+	end := horizon
+	if !r.until.IsZero() && r.until.Before(end) {
+		end = r.until
+	}
+
+	var starts []time.Time
+	emit := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if (r.count == 0 && !t.Before(end)) || len(starts) >= maxOccurrences {
+			return false
+		}
+		starts = append(starts, t)
+		return r.count == 0 || len(starts) < r.count
+	}
+
+	for period := 0; ; period++ {
+		var candidates []time.Time
+		switch r.freq {
+		case "DAILY":
+			t := start.AddDate(0, 0, period*r.interval)
+			if len(r.byDay) == 0 || slices.Contains(r.byDay, t.Weekday()) {
+				candidates = []time.Time{t}
+			}
+		case "WEEKLY":
+			weekStart := periodStart(r, start, period)
+			if len(r.byDay) == 0 {
+				candidates = []time.Time{start.AddDate(0, 0, 7*period*r.interval)}
+			}
+			for _, day := range r.byDay {
+				candidates = append(candidates, weekStart.AddDate(0, 0, (int(day)+6)%7))
+			}
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		case "MONTHLY":
+			// Months without the start's day of month have no occurrence
+			t := start.AddDate(0, period*r.interval, 0)
+			if t.Day() == start.Day() {
+				candidates = []time.Time{t}
+			}
+		case "YEARLY":
+			t := start.AddDate(period*r.interval, 0, 0)
+			if t.Day() == start.Day() {
+				candidates = []time.Time{t}
+			}
+		}
+
+		for _, t := range candidates {
+			if !emit(t) {
+				return starts
+			}
+		}
+		// Stop once even the earliest start of the period is past the end
+		if period >= maxOccurrences || (r.count == 0 && !periodStart(r, start, period).Before(end)) {
+			return starts
+		}
+	}
+}
+
+// periodStart returns the earliest time period can produce: the Monday
+// starting the week for weekly rules, the rule's start time otherwise
+func periodStart(r *rule, start time.Time, period int) time.Time {
+	switch r.freq {
+	case "WEEKLY":
+		return start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*period*r.interval)
+	case "MONTHLY":
+		return start.AddDate(0, period*r.interval, 0)
+	case "YEARLY":
+		return start.AddDate(period*r.interval, 0, 0)
+	default:
+		return start.AddDate(0, 0, period*r.interval)
+	}
+}
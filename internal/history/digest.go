@@ -0,0 +1,77 @@
+package history
+
+import (
+	"sort"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// ProjectDigest summarizes the runs of a period for one project
+type ProjectDigest struct {
+	Project  string `json:"project"`
+	Runs     int    `json:"runs"`
+	Aborted  int    `json:"aborted"`
+	Archived int    `json:"archived"`
+	Failed   int    `json:"failed"`
+	Skipped  int    `json:"skipped"`
+	// Issues that failed in the project's most recent run of the period
+	StillFailing []string `json:"stillFailing,omitempty"`
+}
+
+// Digest aggregates the runs started at or after since, per project. The
+// project of an issue comes from its key. Digests are ordered by project.
+func Digest(runs []Run, since time.Time) []ProjectDigest {
+	digests := make(map[string]*ProjectDigest)
+	lastFailing := make(map[string][]string)
+
+	for _, run := range runs {
+		if run.StartedAt.Before(since) {
+			continue
+		}
+
+		touched := make(map[string]bool)
+		failing := make(map[string][]string)
+		for _, issue := range run.Issues {
+			project := jira.KeyProject(issue.Key)
+			if project == "" {
+				project = run.ProjectKey
+			}
+			d := digests[project]
+			if d == nil {
+				d = &ProjectDigest{Project: project}
+				digests[project] = d
+			}
+			touched[project] = true
+			switch issue.Status {
+			case StatusArchived:
+				d.Archived++
+			case StatusFailed:
+				d.Failed++
+				failing[project] = append(failing[project], issue.Key)
+			case StatusSkipped:
+				d.Skipped++
+			}
+		}
+
+		for project := range touched {
+			digests[project].Runs++
+			if run.Aborted {
+				digests[project].Aborted++
+			}
+			// Runs are oldest first, so the last run seen wins
+			lastFailing[project] = failing[project]
+		}
+	}
+
+	result := make([]ProjectDigest, 0, len(digests))
+	for project, d := range digests {
+		d.StillFailing = lastFailing[project]
+		jira.SortKeys(d.StillFailing)
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Project < result[j].Project
+	})
+	return result
+}
@@ -0,0 +1,170 @@
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Issue outcome statuses
+const (
+	StatusArchived = "archived"
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped"
+)
+
+// Run is a single archive run as recorded in the history file
+type Run struct {
+	ID         string         `json:"id"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	Selector   string         `json:"selector"`
+	ProjectKey string         `json:"projectKey"`
+	Label      string         `json:"label"`
+	PolicyHash string         `json:"policyHash,omitempty"`
+	Total      int            `json:"total"`
+	Succeeded  int            `json:"succeeded"`
+	Failed     int            `json:"failed"`
+	Skipped    int            `json:"skipped"`
+	Aborted    bool           `json:"aborted,omitempty"`
+	Issues     []IssueOutcome `json:"issues"`
+}
+
+// IssueOutcome is the result for one issue within a run
+type IssueOutcome struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Permanent failures will not succeed on retry
+	Permanent bool `json:"permanent,omitempty"`
+}
+
+// Store persists runs as JSON lines in a file, oldest first
+type Store struct {
+	path string
+}
+
+// Open returns a store backed by path. The file is created on first write.
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// NewRunID returns a sortable, unique run identifier
+func NewRunID(t time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// Append adds a run to the end of the history
+func (s *Store) Append(run Run) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return f.Close()
+}
+
+// Runs returns all recorded runs, oldest first. A missing file means no runs.
+func (s *Store) Runs() ([]Run, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	// Runs with many issues produce long lines
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("failed to parse history file: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return runs, nil
+}
+
+// FindRun returns the run with the given ID
+func FindRun(runs []Run, id string) (Run, bool) {
+	for _, run := range runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return Run{}, false
+}
+
+// ChronicFailures returns issues that failed in at least minRuns
+// consecutive runs, counting back from the most recent run, mapped to the
+// number of consecutive failures
+func ChronicFailures(runs []Run, minRuns int) map[string]int {
+	streaks := make(map[string]int)
+	broken := make(map[string]bool)
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		outcomes := make(map[string]string, len(runs[i].Issues))
+		for _, issue := range runs[i].Issues {
+			outcomes[issue.Key] = issue.Status
+		}
+
+		// Candidates are the issues failing in the most recent run
+		if i == len(runs)-1 {
+			for key, status := range outcomes {
+				if status == StatusFailed {
+					streaks[key] = 1
+				}
+			}
+			continue
+		}
+
+		for key := range streaks {
+			if broken[key] {
+				continue
+			}
+			if outcomes[key] == StatusFailed {
+				streaks[key]++
+			} else {
+				broken[key] = true
+			}
+		}
+	}
+
+	chronic := make(map[string]int)
+	for key, n := range streaks {
+		if n >= minRuns {
+			chronic[key] = n
+		}
+	}
+	return chronic
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// WriteMetrics writes the run history as Prometheus text-format metrics.
+// The counters are cumulative over every recorded run, so rate() and
+// increase() work as usual; the last_run gauges describe the newest run.
+// The output suits the node_exporter textfile collector and Pushgateway.
+func WriteMetrics(w io.Writer, runs []Run) error {
+	type series struct{ project, outcome string }
+	issues := make(map[series]int)
+	var completed, aborted int
+	var durationSum float64
+	for _, run := range runs {
+		if run.Aborted {
+			aborted++
+		} else {
+			completed++
+		}
+		durationSum += run.FinishedAt.Sub(run.StartedAt).Seconds()
+		for _, issue := range run.Issues {
+			project := jira.KeyProject(issue.Key)
+			if project == "" {
+				project = run.ProjectKey
+			}
+			issues[series{project, issue.Status}]++
+		}
+	}
+
+	keys := make([]series, 0, len(issues))
+	for k := range issues {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].project != keys[j].project {
+			return keys[i].project < keys[j].project
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP jira_archive_issues_total Issues processed by archive runs, by project and outcome.\n")
+	b.WriteString("# TYPE jira_archive_issues_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "jira_archive_issues_total{project=%q,outcome=%q} %d\n", k.project, k.outcome, issues[k])
+	}
+
+	b.WriteString("# HELP jira_archive_runs_total Recorded archive runs; aborted runs stopped before processing every issue.\n")
+	b.WriteString("# TYPE jira_archive_runs_total counter\n")
+	fmt.Fprintf(&b, "jira_archive_runs_total{aborted=\"false\"} %d\n", completed)
+	fmt.Fprintf(&b, "jira_archive_runs_total{aborted=\"true\"} %d\n", aborted)
+
+	b.WriteString("# HELP jira_archive_run_duration_seconds Time spent archiving per run.\n")
+	b.WriteString("# TYPE jira_archive_run_duration_seconds summary\n")
+	fmt.Fprintf(&b, "jira_archive_run_duration_seconds_sum %g\n", durationSum)
+	fmt.Fprintf(&b, "jira_archive_run_duration_seconds_count %d\n", len(runs))
+
+	if len(runs) > 0 {
+		last := runs[len(runs)-1]
+		b.WriteString("# HELP jira_archive_last_run_timestamp_seconds When the newest run finished, in Unix time.\n")
+		b.WriteString("# TYPE jira_archive_last_run_timestamp_seconds gauge\n")
+		fmt.Fprintf(&b, "jira_archive_last_run_timestamp_seconds %d\n", last.FinishedAt.Unix())
+
+		b.WriteString("# HELP jira_archive_last_run_duration_seconds Time the newest run spent archiving.\n")
+		b.WriteString("# TYPE jira_archive_last_run_duration_seconds gauge\n")
+		fmt.Fprintf(&b, "jira_archive_last_run_duration_seconds %g\n", last.FinishedAt.Sub(last.StartedAt).Seconds())
+
+		b.WriteString("# HELP jira_archive_last_run_issues Issues processed by the newest run, by outcome.\n")
+		b.WriteString("# TYPE jira_archive_last_run_issues gauge\n")
+		fmt.Fprintf(&b, "jira_archive_last_run_issues{outcome=%q} %d\n", StatusArchived, last.Succeeded)
+		fmt.Fprintf(&b, "jira_archive_last_run_issues{outcome=%q} %d\n", StatusFailed, last.Failed)
+		fmt.Fprintf(&b, "jira_archive_last_run_issues{outcome=%q} %d\n", StatusSkipped, last.Skipped)
+
+		b.WriteString("# HELP jira_archive_last_run_failure_ratio Share of the newest run's attempted issues that failed (0-1).\n")
+		b.WriteString("# TYPE jira_archive_last_run_failure_ratio gauge\n")
+		ratio := 0.0
+		if attempted := last.Succeeded + last.Failed; attempted > 0 {
+			ratio = float64(last.Failed) / float64(attempted)
+		}
+		fmt.Fprintf(&b, "jira_archive_last_run_failure_ratio %g\n", ratio)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"fmt"
+	"time"
+)
+
+// Regression metrics
+const (
+	MetricFailureRate = "failure rate"
+	MetricIssueTime   = "time per issue"
+)
+
+// minFailureRate is the baseline failure rate assumed when recent runs had
+// no failures at all, so a single failure does not count as infinitely worse
+const minFailureRate = 0.01
+
+// Regression is a metric of the latest run that is significantly worse than
+// the average of the comparable runs before it
+type Regression struct {
+	Metric   string  `json:"metric"`
+	Current  float64 `json:"current"`
+	Baseline float64 `json:"baseline"`
+	Factor   float64 `json:"factor"`
+	Runs     int     `json:"runs"`
+}
+
+// String describes the regression, e.g. "failure rate up 10.0x vs last 7 runs"
+func (r Regression) String() string {
+	return fmt.Sprintf("%s up %.1fx vs last %d runs", r.Metric, r.Factor, r.Runs)
+}
+
+// Regressions compares the last run against up to baselineRuns earlier runs
+// with the same project and selection and reports every metric at least
+// factor times its baseline average. Aborted and empty runs are not part of
+// the baseline, and nothing is reported until there is a baseline at all.
+func Regressions(runs []Run, baselineRuns int, factor float64) []Regression {
+	if len(runs) == 0 || baselineRuns < 1 {
+		return nil
+	}
+	current := runs[len(runs)-1]
+	if current.Total == 0 {
+		return nil
+	}
+
+	var baseline []Run
+	for i := len(runs) - 2; i >= 0 && len(baseline) < baselineRuns; i-- {
+		run := runs[i]
+		if run.Aborted || run.Total == 0 ||
+			run.ProjectKey != current.ProjectKey || run.Selector != current.Selector {
+			continue
+		}
+		baseline = append(baseline, run)
+	}
+	if len(baseline) == 0 {
+		return nil
+	}
+
+	var failureRate, issueTime float64
+	for _, run := range baseline {
+		failureRate += failureRateOf(run)
+		issueTime += issueTimeOf(run).Seconds()
+	}
+	failureRate /= float64(len(baseline))
+	issueTime /= float64(len(baseline))
+
+	var regressions []Regression
+	add := func(metric string, current, base float64) {
+		if base > 0 && current >= factor*base {
+			regressions = append(regressions, Regression{
+				Metric:   metric,
+				Current:  current,
+				Baseline: base,
+				Factor:   current / base,
+				Runs:     len(baseline),
+			})
+		}
+	}
+	add(MetricFailureRate, failureRateOf(current), max(failureRate, minFailureRate))
+	add(MetricIssueTime, issueTimeOf(current).Seconds(), issueTime)
+	return regressions
+}
+
+func failureRateOf(run Run) float64 {
+	return float64(run.Failed) / float64(run.Total)
+}
+
+// issueTimeOf is the run's duration per issue, which unlike the plain
+// duration stays comparable between a backlog run and a routine one
+func issueTimeOf(run Run) time.Duration {
+	return run.FinishedAt.Sub(run.StartedAt) / time.Duration(run.Total)
+}
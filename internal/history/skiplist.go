@@ -0,0 +1,27 @@
+package history
+
+import "time"
+
+// Ineligible returns the issues whose most recent outcome is a permanent
+// failure recorded in a run started at or after since, mapped to the
+// failure message. Such issues cannot be archived until something changes in
+// Jira, so retrying them every run only adds noise; once since has passed
+// them they are retried again.
+func Ineligible(runs []Run, since time.Time) map[string]string {
+	latest := make(map[string]IssueOutcome)
+	started := make(map[string]time.Time)
+	for _, run := range runs {
+		for _, issue := range run.Issues {
+			latest[issue.Key] = issue
+			started[issue.Key] = run.StartedAt
+		}
+	}
+
+	ineligible := make(map[string]string)
+	for key, outcome := range latest {
+		if outcome.Status == StatusFailed && outcome.Permanent && !started[key].Before(since) {
+			ineligible[key] = outcome.Error
+		}
+	}
+	return ineligible
+}
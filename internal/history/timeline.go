@@ -0,0 +1,61 @@
+package history
+
+import (
+	"sort"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// monthLayout formats the month of a timeline point
+const monthLayout = "2006-01"
+
+// TimelinePoint is the number of issues archived in one project in one month
+type TimelinePoint struct {
+	Month    string `json:"month"`
+	Project  string `json:"project"`
+	Archived int    `json:"archived"`
+	// Cumulative counts the project's archived issues up to and including Month
+	Cumulative int `json:"cumulative"`
+}
+
+// Timeline aggregates archived issues per project per calendar month (UTC)
+// of the run that archived them. Points are ordered by project, then month;
+// months without archives are omitted.
+func Timeline(runs []Run) []TimelinePoint {
+	type bucket struct{ month, project string }
+	counts := make(map[bucket]int)
+	for _, run := range runs {
+		month := run.StartedAt.UTC().Format(monthLayout)
+		for _, issue := range run.Issues {
+			if issue.Status != StatusArchived {
+				continue
+			}
+			project := jira.KeyProject(issue.Key)
+			if project == "" {
+				project = run.ProjectKey
+			}
+			counts[bucket{month, project}]++
+		}
+	}
+
+	points := make([]TimelinePoint, 0, len(counts))
+	for b, n := range counts {
+		points = append(points, TimelinePoint{Month: b.month, Project: b.project, Archived: n})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Project != points[j].Project {
+			return points[i].Project < points[j].Project
+		}
+		return points[i].Month < points[j].Month
+	})
+
+	total := 0
+	for i := range points {
+		if i == 0 || points[i].Project != points[i-1].Project {
+			total = 0
+		}
+		total += points[i].Archived
+		points[i].Cumulative = total
+	}
+	return points
+}
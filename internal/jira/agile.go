@@ -0,0 +1,68 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// boardIssuesPage represents one page of the Agile board issues API
+type boardIssuesPage struct {
+	StartAt    int     `json:"startAt"`
+	MaxResults int     `json:"maxResults"`
+	Total      int     `json:"total"`
+	Issues     []Issue `json:"issues"`
+}
+
+// GetAllBoardIssues retrieves all issues on an Agile board
+func (c *Client) GetAllBoardIssues(boardID string) ([]Issue, error) {
+	endpoint := fmt.Sprintf("%s/rest/agile/1.0/board/%s/issue", c.baseURL, url.PathEscape(boardID))
+
+	var allIssues []Issue
+	startAt := 0
+	maxResults := 100
+
+	for {
+		params := url.Values{}
+		params.Add("startAt", fmt.Sprintf("%d", startAt))
+		params.Add("maxResults", fmt.Sprintf("%d", maxResults))
+		params.Add("fields", c.searchFieldList())
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", endpoint, params.Encode()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, newAPIError(resp, body)
+		}
+
+		var page boardIssuesPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		allIssues = append(allIssues, page.Issues...)
+
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	return allIssues, nil
+}
@@ -0,0 +1,96 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// auditTimeFormat is the timestamp format accepted by the audit records API
+const auditTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// AuditRecord represents an entry in the Jira audit log
+type AuditRecord struct {
+	ID              int64           `json:"id"`
+	Summary         string          `json:"summary"`
+	Created         string          `json:"created"`
+	Category        string          `json:"category"`
+	AuthorAccountID string          `json:"authorAccountId"`
+	ObjectItem      AuditItem       `json:"objectItem"`
+	AssociatedItems []AuditItem     `json:"associatedItems"`
+	ChangedValues   json.RawMessage `json:"changedValues,omitempty"`
+}
+
+// AuditItem represents an object referenced by an audit record
+type AuditItem struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	TypeName string `json:"typeName"`
+}
+
+// auditRecordsPage represents one page of the audit records API
+type auditRecordsPage struct {
+	Offset  int           `json:"offset"`
+	Limit   int           `json:"limit"`
+	Total   int           `json:"total"`
+	Records []AuditRecord `json:"records"`
+}
+
+// GetAuditRecords retrieves all audit records matching filter between from and to.
+// Reading the audit log requires Jira administrator permission.
+func (c *Client) GetAuditRecords(filter string, from, to time.Time) ([]AuditRecord, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/auditing/record", c.baseURL)
+
+	var records []AuditRecord
+	offset := 0
+	limit := 1000 // Maximum page size of the audit API
+
+	for {
+		params := url.Values{}
+		params.Add("offset", fmt.Sprintf("%d", offset))
+		params.Add("limit", fmt.Sprintf("%d", limit))
+		params.Add("from", from.Format(auditTimeFormat))
+		params.Add("to", to.Format(auditTimeFormat))
+		if filter != "" {
+			params.Add("filter", filter)
+		}
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", endpoint, params.Encode()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, newAPIError(resp, body)
+		}
+
+		var page auditRecordsPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		records = append(records, page.Records...)
+
+		offset += len(page.Records)
+		if len(page.Records) == 0 || offset >= page.Total {
+			break
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,59 @@
+package jira
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Authentication methods selected by JIRA_AUTH_METHOD
+const (
+	// AuthBasic sends the account's email and API token
+	AuthBasic = "basic"
+	// AuthOAuth sends an OAuth 2.0 (3LO) access token as a bearer token.
+	// Such tokens are only accepted through
+	// https://api.atlassian.com/ex/jira/{cloudId}, the base URL to use.
+	AuthOAuth = "oauth"
+	// AuthPAT sends a personal access token as a bearer token
+	AuthPAT = "pat"
+)
+
+// SetAuthMethod selects how requests authenticate: AuthBasic (the
+// default), AuthOAuth or AuthPAT. The bearer methods send the client's
+// token on its own and ignore the email.
+func (c *Client) SetAuthMethod(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authMethod = method
+}
+
+// authorize sets the request's credentials
+func (c *Client) authorize(req *http.Request) {
+	c.mu.Lock()
+	method := c.authMethod
+	c.mu.Unlock()
+	Authorize(req, method, c.email, c.apiToken)
+}
+
+// Authorize sets the credentials of a request sent outside a Client, the
+// way a client using method does
+func Authorize(req *http.Request, method, email, token string) {
+	if method == AuthOAuth || method == AuthPAT {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	req.SetBasicAuth(email, token)
+}
+
+// identity distinguishes the users whose metadata is cached: the email, or
+// a fingerprint of the token for bearer methods, which carry no email
+func (c *Client) identity() string {
+	c.mu.Lock()
+	method := c.authMethod
+	c.mu.Unlock()
+	if method != AuthOAuth && method != AuthPAT {
+		return c.email
+	}
+	sum := sha256.Sum256([]byte(c.apiToken))
+	return hex.EncodeToString(sum[:8])
+}
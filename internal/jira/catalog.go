@@ -0,0 +1,189 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/suggest"
+)
+
+// ErrFieldNotFound is returned by FieldID for a name matching no field
+var ErrFieldNotFound = errors.New("no field named")
+
+// DefaultMetadataTTL is how long fields, statuses and issue types are
+// reused before they are looked up again
+const DefaultMetadataTTL = 15 * time.Minute
+
+// Field is a system or custom issue field
+type Field struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Custom bool   `json:"custom"`
+}
+
+// StatusCategory groups statuses into to do ("new"), in progress
+// ("indeterminate") and done ("done")
+type StatusCategory struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// StatusDetails is a workflow status with its category
+type StatusDetails struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	StatusCategory StatusCategory `json:"statusCategory"`
+}
+
+// catalog caches site metadata for every client in the process, so that
+// batches and clients created per run share one lookup per TTL
+var catalog struct {
+	mu      sync.Mutex
+	entries map[string]catalogEntry
+}
+
+type catalogEntry struct {
+	value   any
+	fetched time.Time
+}
+
+// SetMetadataTTL sets how long fields, statuses and issue types are cached
+// (0 or less looks them up on every call)
+func (c *Client) SetMetadataTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metadataTTL = ttl
+}
+
+// Fields returns every system and custom field
+func (c *Client) Fields() ([]Field, error) {
+	return cachedMetadata[[]Field](c, "/rest/api/3/field")
+}
+
+// FieldID returns the ID of the field named nameOrID, case-insensitively,
+// or nameOrID itself if it is already a field ID. The JQL form cf[10010] is
+// accepted for customfield_10010. Custom field names are not unique, so a
+// name shared by several fields is an error naming their IDs.
+func (c *Client) FieldID(nameOrID string) (string, error) {
+	fields, err := c.Fields()
+	if err != nil {
+		return "", err
+	}
+	id := nameOrID
+	if number, ok := strings.CutPrefix(nameOrID, "cf["); ok && strings.HasSuffix(number, "]") {
+		id = "customfield_" + strings.TrimSuffix(number, "]")
+	}
+	for _, f := range fields {
+		if f.ID == id {
+			return f.ID, nil
+		}
+	}
+
+	var matches []string
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+		if strings.EqualFold(f.Name, nameOrID) {
+			matches = append(matches, f.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if similar := suggest.Closest(nameOrID, names, 3); len(similar) > 0 {
+			return "", fmt.Errorf("%w %q; did you mean \"%s\"?", ErrFieldNotFound, nameOrID, strings.Join(similar, `", "`))
+		}
+		return "", fmt.Errorf("%w %q", ErrFieldNotFound, nameOrID)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("field name %q is ambiguous (%s); use one of the IDs instead", nameOrID, strings.Join(matches, ", "))
+	}
+}
+
+// Statuses returns every workflow status with its category
+func (c *Client) Statuses() ([]StatusDetails, error) {
+	return cachedMetadata[[]StatusDetails](c, "/rest/api/3/status")
+}
+
+// StatusCategory returns the category key ("new", "indeterminate" or
+// "done") of the status named name
+func (c *Client) StatusCategory(name string) (string, error) {
+	statuses, err := c.Statuses()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range statuses {
+		if strings.EqualFold(s.Name, name) {
+			return s.StatusCategory.Key, nil
+		}
+	}
+	return "", fmt.Errorf("no status named %q", name)
+}
+
+// IssueTypes returns every issue type the user can see
+func (c *Client) IssueTypes() ([]IssueType, error) {
+	return cachedMetadata[[]IssueType](c, "/rest/api/3/issuetype")
+}
+
+// cachedMetadata returns the metadata at path from the catalog, fetching it
+// once the client's TTL has passed
+func cachedMetadata[T any](c *Client, path string) (T, error) {
+	c.mu.Lock()
+	ttl := c.metadataTTL
+	c.mu.Unlock()
+	key := c.baseURL + " " + c.identity() + " " + path
+
+	catalog.mu.Lock()
+	entry, ok := catalog.entries[key]
+	catalog.mu.Unlock()
+	if ok && ttl > 0 && time.Since(entry.fetched) < ttl {
+		return entry.value.(T), nil
+	}
+
+	var value T
+	if err := c.getMetadata(path, &value); err != nil {
+		return value, err
+	}
+	catalog.mu.Lock()
+	if catalog.entries == nil {
+		catalog.entries = make(map[string]catalogEntry)
+	}
+	catalog.entries[key] = catalogEntry{value: value, fetched: time.Now()}
+	catalog.mu.Unlock()
+	return value, nil
+}
+
+// getMetadata decodes the response of a metadata endpoint into v
+func (c *Client) getMetadata(path string, v any) error {
+	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.doConditional(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -2,21 +2,66 @@ package jira
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+// Page sizes of the issue search API
+const (
+	// DefaultSearchPageSize is Jira's recommended page size
+	DefaultSearchPageSize = 100
+	// MaxSearchPageSize is the most issues the search returns per page. Jira
+	// may return fewer when many fields are requested.
+	MaxSearchPageSize = 5000
 )
 
+// ErrAPIBudgetExhausted is returned once the per-run API call budget is used up
+var ErrAPIBudgetExhausted = errors.New("API call budget exhausted")
+
 // Client represents a JIRA API client
 type Client struct {
 	baseURL    string
 	email      string
 	apiToken   string
 	httpClient *http.Client
+
+	mu         sync.Mutex
+	calls      int
+	maxCalls   int
+	maxRetries int
+	stats      RequestStats
+
+	retryBaseDelay time.Duration
+	retryJitter    float64
+
+	latencies   map[string][]time.Duration
+	slowRequest time.Duration
+	hedgeAfter  time.Duration
+
+	metadataCache string
+	metadataTTL   time.Duration
+
+	authMethod string
+
+	searchPageSize int
+	extraFields    []string
+	expand         []string
+	pageHook       func(jql, nextPageToken string, fetched int)
+
+	logger logging.Logger
 }
 
 // Issue represents a JIRA issue
@@ -24,11 +69,67 @@ type Issue struct {
 	ID     string      `json:"id"`
 	Key    string      `json:"key"`
 	Fields IssueFields `json:"fields"`
+
+	// Expanded holds the members added by SEARCH_EXPAND, such as changelog
+	Expanded map[string]json.RawMessage `json:"-"`
 }
 
 // IssueFields represents fields in a JIRA issue
 type IssueFields struct {
-	Summary string `json:"summary"`
+	Summary      string     `json:"summary"`
+	Project      *Project   `json:"project,omitempty"`
+	IssueType    *IssueType `json:"issuetype,omitempty"`
+	Labels       []string   `json:"labels,omitempty"`
+	Status       *Status    `json:"status,omitempty"`
+	Updated      string     `json:"updated,omitempty"`
+	Assignee     *User      `json:"assignee,omitempty"`
+	ArchivedDate string     `json:"archiveddate,omitempty"`
+	ArchivedBy   *User      `json:"archivedby,omitempty"`
+	// Security is the issue security level, nil if none is set
+	Security *SecurityLevel `json:"security,omitempty"`
+	// Subtasks are the issue's subtasks with their summary, status and type
+	Subtasks []Issue `json:"subtasks,omitempty"`
+
+	// Extra holds the fields added by SEARCH_FIELDS
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Project represents a JIRA project reference
+type Project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// ProjectTypeKey is software, service_desk or business
+	ProjectTypeKey string `json:"projectTypeKey,omitempty"`
+}
+
+// ProjectTypeServiceDesk is the project type of Jira Service Management
+// projects, whose issues are customer requests
+const ProjectTypeServiceDesk = "service_desk"
+
+// SecurityLevel represents an issue security level
+type SecurityLevel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// IssueType represents a JIRA issue type
+type IssueType struct {
+	Name    string `json:"name"`
+	Subtask bool   `json:"subtask"`
+}
+
+// Status represents a JIRA issue status
+type Status struct {
+	Name           string          `json:"name"`
+	StatusCategory *StatusCategory `json:"statusCategory,omitempty"`
+}
+
+// User represents a JIRA user
+type User struct {
+	AccountID    string `json:"accountId"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress,omitempty"`
 }
 
 // SearchResult represents the result of a JQL search
@@ -41,23 +142,143 @@ type SearchResult struct {
 // NewClient creates a new JIRA API client
 func NewClient(baseURL, email, apiToken string) *Client {
 	return &Client{
-		baseURL:  baseURL,
-		email:    email,
-		apiToken: apiToken,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:        baseURL,
+		email:          email,
+		apiToken:       apiToken,
+		httpClient:     transport.Client(30 * time.Second),
+		maxRetries:     defaultMaxRetries,
+		retryBaseDelay: DefaultRetryBaseDelay,
+		retryJitter:    DefaultRetryJitter,
+		searchPageSize: DefaultSearchPageSize,
+		metadataTTL:    DefaultMetadataTTL,
+	}
+}
+
+// SetLogger sends the client's logs to logger instead of the standard logger
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logging.NewLogger(logger)
+}
+
+// Logger returns the client's logger, for code logging on its behalf
+func (c *Client) Logger() logging.Logger {
+	return c.logger
+}
+
+// SetSearchPageSize sets how many issues GetAllIssues requests per page,
+// between 1 and MaxSearchPageSize
+func (c *Client) SetSearchPageSize(n int) {
+	c.searchPageSize = n
+}
+
+// SetMaxAPICalls limits the number of requests this client sends (0 = unlimited)
+func (c *Client) SetMaxAPICalls(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxCalls = n
+}
+
+// APICalls returns the number of requests sent so far
+func (c *Client) APICalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// BudgetExhausted reports whether no further requests may be sent
+func (c *Client) BudgetExhausted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxCalls > 0 && c.calls >= c.maxCalls
+}
+
+// do sends a request, enforcing the rate limit and the API call budget and
+// retrying throttled or temporarily failing requests and dropped connections
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		paced, err := c.pace(req)
+		if err != nil {
+			return nil, err
+		}
+		req = paced
+
+		c.mu.Lock()
+		if c.maxCalls > 0 && c.calls >= c.maxCalls {
+			c.mu.Unlock()
+			return nil, ErrAPIBudgetExhausted
+		}
+		c.calls++
+		c.mu.Unlock()
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		c.observe(req, resp, time.Since(start))
+		if resp != nil {
+			c.logger.Debugf("%s %s: %s in %s", req.Method, req.URL, resp.Status, time.Since(start).Round(time.Millisecond))
+		}
+		if err != nil && !transient(req, err) {
+			return nil, err
+		}
+		if err == nil && !retryable(resp.StatusCode) {
+			return resp, nil
+		}
+
+		c.mu.Lock()
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			c.stats.RateLimited++
+		}
+		maxRetries, base, jitter := c.maxRetries, c.retryBaseDelay, c.retryJitter
+		c.mu.Unlock()
+
+		// Requests whose body cannot be replayed are not retried
+		if attempt >= maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		wait := retryDelay(resp, attempt, base, jitter, time.Now())
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			c.logger.Warnf("Retrying %s %s in %s after %v", req.Method, req.URL.Path, wait.Round(time.Millisecond), err)
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		c.mu.Lock()
+		c.stats.Retries++
+		c.stats.Backoff += wait
+		c.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
 	}
 }
 
 // SearchIssues searches for issues using JQL with the new search/jql endpoint
 func (c *Client) SearchIssues(jql, nextPageToken string, maxResults int) (*SearchResult, error) {
+	return c.SearchIssuesContext(context.Background(), jql, nextPageToken, maxResults)
+}
+
+// SearchIssuesContext is SearchIssues, abandoning the request once ctx is done
+func (c *Client) SearchIssuesContext(ctx context.Context, jql, nextPageToken string, maxResults int) (*SearchResult, error) {
 	endpoint := fmt.Sprintf("%s/rest/api/3/search/jql", c.baseURL)
 
 	params := url.Values{}
 	params.Add("jql", jql)
 	params.Add("maxResults", fmt.Sprintf("%d", maxResults))
-	params.Add("fields", "summary")
+	params.Add("fields", c.searchFieldList())
+	if len(c.expand) > 0 {
+		params.Add("expand", strings.Join(c.expand, ","))
+	}
 
 	if nextPageToken != "" {
 		params.Add("nextPageToken", nextPageToken)
@@ -65,17 +286,17 @@ func (c *Client) SearchIssues(jql, nextPageToken string, maxResults int) (*Searc
 
 	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
 
-	log.Printf("fullURL: %s\n", fullURL)
+	c.logger.Debugf("fullURL: %s\n", fullURL)
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.email, c.apiToken)
+	c.authorize(req)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doHedged(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -83,7 +304,7 @@ func (c *Client) SearchIssues(jql, nextPageToken string, maxResults int) (*Searc
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	var result SearchResult
@@ -94,19 +315,123 @@ func (c *Client) SearchIssues(jql, nextPageToken string, maxResults int) (*Searc
 	return &result, nil
 }
 
+// GetIssue fetches a single issue with the given comma-separated fields
+func (c *Client) GetIssue(issueIDOrKey, fields string) (*Issue, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s", c.baseURL, url.PathEscape(issueIDOrKey))
+
+	params := url.Values{}
+	if fields != "" {
+		params.Add("fields", fields)
+	}
+
+	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, body)
+	}
+
+	var issue Issue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &issue, nil
+}
+
 // ArchiveRequest represents the request body for bulk archiving
 type ArchiveRequest struct {
 	IssueIdsOrKeys []string `json:"issueIdsOrKeys"`
 }
 
+// Error categories reported by the bulk archive API
+const (
+	ArchiveErrorIssueIsSubtask       = "issueIsSubtask"
+	ArchiveErrorArchivedProjects     = "issuesInArchivedProjects"
+	ArchiveErrorUnlicensedProjects   = "issuesInUnlicensedProjects"
+	ArchiveErrorIssuesNotFound       = "issuesNotFound"
+	ArchiveErrorIssuesNotPermissible = "issuesNotPermissible"
+)
+
 // ArchiveResponse represents the response from bulk archive API
 type ArchiveResponse struct {
-	Errors map[string]string `json:"errors,omitempty"`
+	// Errors groups rejected issues by error category
+	Errors                map[string]ArchiveError `json:"errors,omitempty"`
+	NumberOfIssuesUpdated int                     `json:"numberOfIssuesUpdated"`
 }
 
+// ArchiveError lists the issues rejected for one error category
+type ArchiveError struct {
+	Count          int      `json:"count"`
+	IssueIdsOrKeys []string `json:"issueIdsOrKeys"`
+	Message        string   `json:"message"`
+}
+
+// IssueError describes why a single issue was rejected
+type IssueError struct {
+	Category string
+	Message  string
+}
+
+// IssueErrors indexes the rejected issues by the ID or key that was sent
+func (r *ArchiveResponse) IssueErrors() map[string]IssueError {
+	errs := make(map[string]IssueError)
+	for category, e := range r.Errors {
+		message := e.Message
+		if message == "" {
+			message = category
+		}
+		for _, idOrKey := range e.IssueIdsOrKeys {
+			errs[idOrKey] = IssueError{Category: category, Message: message}
+		}
+	}
+	return errs
+}
+
+// IsPermanent reports whether retrying the issue cannot succeed without a
+// change on the Jira side (unlike, for example, a missing or moved issue)
+func (e IssueError) IsPermanent() bool {
+	return e.Category != ArchiveErrorIssuesNotFound
+}
+
+// MaxArchiveIssues is the most issues one bulk archive or unarchive request
+// may carry
+const MaxArchiveIssues = 1000
+
 // ArchiveIssues archives multiple issues in a single API call
 func (c *Client) ArchiveIssues(issueKeys []string) (*ArchiveResponse, error) {
-	endpoint := fmt.Sprintf("%s/rest/api/3/issue/archive", c.baseURL)
+	return c.ArchiveIssuesContext(context.Background(), issueKeys)
+}
+
+// ArchiveIssuesContext is ArchiveIssues, abandoning the request once ctx is
+// done. Jira may still archive the issues of an abandoned request.
+func (c *Client) ArchiveIssuesContext(ctx context.Context, issueKeys []string) (*ArchiveResponse, error) {
+	return c.bulkArchive(ctx, "archive", issueKeys)
+}
+
+// UnarchiveIssues restores multiple archived issues in a single API call
+func (c *Client) UnarchiveIssues(issueKeys []string) (*ArchiveResponse, error) {
+	return c.bulkArchive(context.Background(), "unarchive", issueKeys)
+}
+
+// bulkArchive calls the archive or unarchive endpoint, which share their
+// request and response formats
+func (c *Client) bulkArchive(ctx context.Context, operation string, issueKeys []string) (*ArchiveResponse, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s", c.baseURL, operation)
 
 	requestBody := ArchiveRequest{
 		IssueIdsOrKeys: issueKeys,
@@ -117,16 +442,16 @@ func (c *Client) ArchiveIssues(issueKeys []string) (*ArchiveResponse, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.email, c.apiToken)
+	c.authorize(req)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -137,7 +462,7 @@ func (c *Client) ArchiveIssues(issueKeys []string) (*ArchiveResponse, error) {
 
 	// Archive API returns 200 or 204 on success
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body)
 	}
 
 	// Parse response if there's a body
@@ -151,21 +476,106 @@ func (c *Client) ArchiveIssues(issueKeys []string) (*ArchiveResponse, error) {
 	return &archiveResp, nil
 }
 
+// LabelJQL builds the query selecting issues with a label in a project
+func LabelJQL(projectKey, label string) string {
+	return fmt.Sprintf("project = %s AND labels = %s", projectKey, label)
+}
+
+// AgeJQL builds the clauses selecting issues last updated at least
+// olderThanDays days ago and resolved before resolvedBefore, a date
+// (2006-01-02) or a number of days ago ("90d"). Zero values are left out.
+func AgeJQL(olderThanDays int, resolvedBefore string) string {
+	var clauses []string
+	if olderThanDays > 0 {
+		clauses = append(clauses, fmt.Sprintf("updated <= -%dd", olderThanDays))
+	}
+	if resolvedBefore != "" {
+		if strings.HasSuffix(resolvedBefore, "d") {
+			clauses = append(clauses, "resolutiondate <= -"+resolvedBefore)
+		} else {
+			clauses = append(clauses, fmt.Sprintf("resolutiondate <= %q", resolvedBefore))
+		}
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// ExcludeJQL builds the clauses leaving out issues with any of labels, in
+// any of statuses or of any of issueTypes. Empty lists are left out.
+func ExcludeJQL(labels, statuses, issueTypes []string) string {
+	var clauses []string
+	if len(labels) > 0 {
+		// labels not in (...) alone would also drop issues without labels
+		clauses = append(clauses, fmt.Sprintf("(labels is EMPTY OR labels not in (%s))", quoteJQLList(labels)))
+	}
+	if len(statuses) > 0 {
+		clauses = append(clauses, fmt.Sprintf("status not in (%s)", quoteJQLList(statuses)))
+	}
+	if len(issueTypes) > 0 {
+		clauses = append(clauses, fmt.Sprintf("issuetype not in (%s)", quoteJQLList(issueTypes)))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// quoteJQLList quotes values as a comma-separated JQL list
+func quoteJQLList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// orderByPattern matches a trailing ORDER BY clause outside of quotes
+var orderByPattern = regexp.MustCompile(`(?is)\border\s+by\s+[^"']*$`)
+
+// HasOrderBy reports whether query ends in an ORDER BY clause. Queries are
+// combined with other clauses inside parentheses, where ORDER BY is invalid,
+// and issues are processed in key order anyway.
+func HasOrderBy(query string) bool {
+	return orderByPattern.MatchString(query)
+}
+
+// AndJQL narrows query to the issues also matching clause, if any
+func AndJQL(query, clause string) string {
+	if clause == "" {
+		return query
+	}
+	return fmt.Sprintf("(%s) AND %s", query, clause)
+}
+
+// MatchesJQL reports whether the issue is returned by the given JQL query
+func (c *Client) MatchesJQL(issueKey, jql string) (bool, error) {
+	result, err := c.SearchIssues(fmt.Sprintf("key = %s AND (%s)", issueKey, jql), "", 1)
+	if err != nil {
+		return false, err
+	}
+	return len(result.Issues) > 0, nil
+}
+
 // GetAllIssuesByLabel retrieves all issues with a specific label in a project
 func (c *Client) GetAllIssuesByLabel(projectKey, label string) ([]Issue, error) {
-	jql := fmt.Sprintf("project = %s AND labels = %s", projectKey, label)
+	return c.GetAllIssues(LabelJQL(projectKey, label))
+}
 
+// GetAllIssues retrieves all issues matching a JQL query, following pagination
+func (c *Client) GetAllIssues(jql string) ([]Issue, error) {
+	return c.GetAllIssuesContext(context.Background(), jql)
+}
+
+// GetAllIssuesContext is GetAllIssues, stopping once ctx is done
+func (c *Client) GetAllIssuesContext(ctx context.Context, jql string) ([]Issue, error) {
 	var allIssues []Issue
 	nextPageToken := ""
-	maxResults := 100 // JIRA's recommended batch size
-
 	for {
-		result, err := c.SearchIssues(jql, nextPageToken, maxResults)
+		result, err := c.SearchIssuesContext(ctx, jql, nextPageToken, c.searchPageSize)
 		if err != nil {
 			return nil, err
 		}
 
 		allIssues = append(allIssues, result.Issues...)
+		if c.pageHook != nil {
+			c.pageHook(jql, result.NextPageToken, len(allIssues))
+		}
 
 		// Check if there are more pages
 		if result.NextPageToken == "" {
@@ -0,0 +1,64 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// adfNode is a node of the Atlassian Document Format used by API v3 bodies
+type adfNode struct {
+	Type    string    `json:"type"`
+	Version int       `json:"version,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Content []adfNode `json:"content,omitempty"`
+}
+
+// textToADF converts plain text to an ADF document, one paragraph per line
+func textToADF(text string) adfNode {
+	doc := adfNode{Type: "doc", Version: 1}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		paragraph := adfNode{Type: "paragraph"}
+		if line != "" {
+			paragraph.Content = []adfNode{{Type: "text", Text: line}}
+		}
+		doc.Content = append(doc.Content, paragraph)
+	}
+	return doc
+}
+
+// AddComment adds a plain-text comment to an issue
+func (c *Client) AddComment(issueIDOrKey, text string) error {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s/comment", c.baseURL, url.PathEscape(issueIDOrKey))
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"body": textToADF(text)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package jira
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// supportHeaders are the response headers Atlassian support asks for when
+// investigating a failed request: request and trace IDs and the rate-limit
+// state at the time
+var supportHeaders = []string{
+	"X-Arequestid",
+	"Atl-Traceid",
+	"X-Request-Id",
+	"Retry-After",
+	"X-Ratelimit-Limit",
+	"X-Ratelimit-Remaining",
+	"X-Ratelimit-Reset",
+	"X-Ratelimit-Nearlimit",
+	"Ratelimit-Reason",
+}
+
+// APIError is a response from the Jira API with an unexpected status
+type APIError struct {
+	StatusCode int
+	Body       string
+	// Header holds the support headers present on the response
+	Header http.Header
+}
+
+// newAPIError builds an APIError from a response whose body has been read
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	header := make(http.Header)
+	for _, name := range supportHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Body: string(body), Header: header}
+}
+
+// Error includes the support headers, so logs and reports carry the
+// identifiers needed to file a support ticket
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+	var ids []string
+	for _, name := range supportHeaders {
+		if value := e.Header.Get(name); value != "" {
+			ids = append(ids, name+"="+value)
+		}
+	}
+	if len(ids) > 0 {
+		msg += " [" + strings.Join(ids, " ") + "]"
+	}
+	return msg
+}
+
+// RequestID returns Atlassian's ID for the failed request, if present
+func (e *APIError) RequestID() string {
+	return e.Header.Get("X-Arequestid")
+}
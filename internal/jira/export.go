@@ -0,0 +1,45 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ExportIssue returns the full JSON of an issue as Jira sends it: every
+// field including comments and attachment metadata, the rendered fields and
+// the field names, so that an archived issue can be inspected offline
+func (c *Client) ExportIssue(issueIDOrKey string) (json.RawMessage, error) {
+	params := url.Values{}
+	params.Add("fields", "*all")
+	params.Add("expand", "renderedFields,names")
+	fullURL := fmt.Sprintf("%s/rest/api/3/issue/%s?%s", c.baseURL, url.PathEscape(issueIDOrKey), params.Encode())
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("failed to decode response: invalid JSON")
+	}
+	return json.RawMessage(body), nil
+}
@@ -0,0 +1,116 @@
+package jira
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SetSearchHedge sends a second, identical search request when the first
+// has not answered within after, and uses whichever answers first; the
+// other is cancelled. Both count towards the API call budget. Only search
+// GETs are hedged, never archive requests (0 disables).
+func (c *Client) SetSearchHedge(after time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hedgeAfter = after
+}
+
+// hedgedResponse is the outcome of one of the hedged requests
+type hedgedResponse struct {
+	resp  *http.Response
+	err   error
+	hedge bool
+}
+
+// cancelOnClose releases a hedged request's context once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// doHedged is do for idempotent GETs, hedged when SetSearchHedge is set
+func (c *Client) doHedged(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	after := c.hedgeAfter
+	c.mu.Unlock()
+	if after <= 0 || req.Method != http.MethodGet {
+		return c.do(req)
+	}
+
+	responses := make(chan hedgedResponse, 2)
+	// cancels[0] belongs to the first request, cancels[1] to the hedge
+	var cancels [2]context.CancelFunc
+	send := func(hedge bool) {
+		ctx, cancel := context.WithCancel(req.Context())
+		if hedge {
+			cancels[1] = cancel
+		} else {
+			cancels[0] = cancel
+		}
+		go func() {
+			resp, err := c.do(req.Clone(ctx))
+			responses <- hedgedResponse{resp: resp, err: err, hedge: hedge}
+		}()
+	}
+	send(false)
+	pending := 1
+
+	timer := time.NewTimer(after)
+	defer timer.Stop()
+	var winner hedgedResponse
+	select {
+	case winner = <-responses:
+		pending--
+	case <-timer.C:
+		c.mu.Lock()
+		c.stats.Hedged++
+		c.mu.Unlock()
+		c.logger.Infof("Search request slower than %s; sending a hedged request", after)
+		send(true)
+		pending++
+		winner = <-responses
+		pending--
+		// A failed attempt only loses if the other one may still succeed
+		if winner.err != nil {
+			winner = <-responses
+			pending--
+		}
+	}
+
+	winnerIndex, loserIndex := 0, 1
+	if winner.hedge {
+		winnerIndex, loserIndex = 1, 0
+	}
+	if pending > 0 {
+		// Abandon the slower request and release its connection
+		cancels[loserIndex]()
+		go func() {
+			if loser := <-responses; loser.resp != nil {
+				io.Copy(io.Discard, loser.resp.Body)
+				loser.resp.Body.Close()
+			}
+		}()
+	} else if cancels[loserIndex] != nil {
+		cancels[loserIndex]()
+	}
+
+	if winner.err != nil {
+		cancels[winnerIndex]()
+		return nil, winner.err
+	}
+	if winner.hedge {
+		c.mu.Lock()
+		c.stats.HedgeWins++
+		c.mu.Unlock()
+	}
+	winner.resp.Body = cancelOnClose{ReadCloser: winner.resp.Body, cancel: cancels[winnerIndex]}
+	return winner.resp, nil
+}
@@ -0,0 +1,105 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hedgeServer answers searches with the issue PROJ-<n> for the nth request,
+// holding the first request for delay or until it is cancelled
+func hedgeServer(t *testing.T, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 1 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SearchResult{Issues: []Issue{{Key: fmt.Sprintf("PROJ-%d", n)}}, Total: 1})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestSearchHedgeAnswersFromTheFasterRequest(t *testing.T) {
+	server, calls := hedgeServer(t, 5*time.Second)
+	client := newTestClient(server.URL)
+	client.SetSearchHedge(20 * time.Millisecond)
+
+	start := time.Now()
+	result, err := client.SearchIssues("project = PROJ", "", 50)
+	if err != nil {
+		t.Fatalf("SearchIssues() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SearchIssues() took %s, want the hedge to answer", elapsed)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Key != "PROJ-2" {
+		t.Errorf("issues = %v, want the hedged request's PROJ-2", result.Issues)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+	if stats := client.Stats(); stats.Hedged != 1 || stats.HedgeWins != 1 || stats.Requests != 2 {
+		t.Errorf("stats = %+v, want 1 hedge won out of 2 requests", stats)
+	}
+}
+
+func TestSearchHedgeNotSentForFastRequests(t *testing.T) {
+	server, calls := hedgeServer(t, 0)
+	client := newTestClient(server.URL)
+	client.SetSearchHedge(2 * time.Second)
+
+	result, err := client.SearchIssues("project = PROJ", "", 50)
+	if err != nil {
+		t.Fatalf("SearchIssues() error = %v", err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Key != "PROJ-1" {
+		t.Errorf("issues = %v, want PROJ-1", result.Issues)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+	if stats := client.Stats(); stats.Hedged != 0 {
+		t.Errorf("Hedged = %d, want 0", stats.Hedged)
+	}
+}
+
+func TestSearchHedgeFallsBackWhenOneRequestFails(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 2 {
+			// The hedge's connection drops; the slow first request still answers
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SearchResult{Issues: []Issue{{Key: "PROJ-1"}}, Total: 1})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetMaxRetries(0)
+	client.SetSearchHedge(20 * time.Millisecond)
+	result, err := client.SearchIssues("project = PROJ", "", 50)
+	if err != nil {
+		t.Fatalf("SearchIssues() error = %v", err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Key != "PROJ-1" {
+		t.Errorf("issues = %v, want PROJ-1", result.Issues)
+	}
+}
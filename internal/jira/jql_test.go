@@ -0,0 +1,19 @@
+package jira
+
+import "testing"
+
+func TestHasOrderBy(t *testing.T) {
+	tests := map[string]bool{
+		"project = A":                       false,
+		"project = A ORDER BY created DESC": true,
+		"project = A order  by key":         true,
+		`summary ~ "order by x" AND x = 1`:  false,
+		"status = Done ORDER BY rank, key":  true,
+		"labels = orderby":                  false,
+	}
+	for query, want := range tests {
+		if got := HasOrderBy(query); got != want {
+			t.Errorf("HasOrderBy(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package jira
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyPattern matches an issue key such as PROJ-123
+var keyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+
+// ValidKey reports whether key is a well-formed issue key
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// NormalizeKeys trims and upper-cases manually supplied issue keys and
+// removes duplicates, keeping the first occurrence. Entries that are not
+// issue keys are returned separately, as given.
+func NormalizeKeys(raw []string) (keys, invalid []string) {
+	seen := make(map[string]bool, len(raw))
+	for _, entry := range raw {
+		key := strings.ToUpper(strings.TrimSpace(entry))
+		if !ValidKey(key) {
+			invalid = append(invalid, entry)
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, invalid
+}
+
+// CompareKeys orders issue keys by project key and then numerically by
+// issue number, so PROJ-9 sorts before PROJ-10. Values that are not issue
+// keys fall back to plain string order.
+func CompareKeys(a, b string) int {
+	projectA, numA, okA := splitKey(a)
+	projectB, numB, okB := splitKey(b)
+	if okA && okB {
+		if c := strings.Compare(projectA, projectB); c != 0 {
+			return c
+		}
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// SortKeys sorts issue keys in place using CompareKeys
+func SortKeys(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return CompareKeys(keys[i], keys[j]) < 0
+	})
+}
+
+// SortIssues sorts issues in place by key using CompareKeys
+func SortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return CompareKeys(issues[i].Key, issues[j].Key) < 0
+	})
+}
+
+func splitKey(key string) (string, int, bool) {
+	i := strings.LastIndexByte(key, '-')
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return key[:i], n, true
+}
+
+// KeyProject returns the project key part of an issue key, or "" if key is
+// not an issue key
+func KeyProject(key string) string {
+	project, _, _ := splitKey(key)
+	return project
+}
+
+// ParseProjectKeys splits a comma-separated list of project keys into
+// upper-case keys, dropping blanks and duplicates
+func ParseProjectKeys(list string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(list, ",") {
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
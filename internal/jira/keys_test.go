@@ -0,0 +1,40 @@
+package jira
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeKeys(t *testing.T) {
+	keys, invalid := NormalizeKeys([]string{" proj-1 ", "PROJ-2", "proj-1", "OPS_2-10", "PROJ", "-1", "PROJ-1a", "", "1PROJ-3"})
+	if want := []string{"PROJ-1", "PROJ-2", "OPS_2-10"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+	if want := []string{"PROJ", "-1", "PROJ-1a", "", "1PROJ-3"}; !slices.Equal(invalid, want) {
+		t.Errorf("invalid = %q, want %q", invalid, want)
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	keys := []string{"PROJ-10", "OPS-3", "PROJ-9", "PROJ-100", "not a key", "OPS-20", "PROJ-9"}
+	SortKeys(keys)
+	want := []string{"OPS-3", "OPS-20", "PROJ-9", "PROJ-9", "PROJ-10", "PROJ-100", "not a key"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("SortKeys() = %q, want %q", keys, want)
+	}
+}
+
+func TestKeyProject(t *testing.T) {
+	for key, want := range map[string]string{"PROJ-12": "PROJ", "MY-PROJ-3": "MY-PROJ", "PROJ": "", "PROJ-x": ""} {
+		if got := KeyProject(key); got != want {
+			t.Errorf("KeyProject(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestParseProjectKeys(t *testing.T) {
+	got := ParseProjectKeys(" proj, OPS,,PROJ , it ")
+	if want := []string{"PROJ", "OPS", "IT"}; !slices.Equal(got, want) {
+		t.Errorf("ParseProjectKeys() = %q, want %q", got, want)
+	}
+}
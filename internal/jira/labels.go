@@ -0,0 +1,114 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// UpdateLabels adds and removes labels on an issue without touching other labels
+func (c *Client) UpdateLabels(issueIDOrKey string, add, remove []string) error {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s", c.baseURL, url.PathEscape(issueIDOrKey))
+
+	var operations []map[string]string
+	for _, label := range add {
+		operations = append(operations, map[string]string{"add": label})
+	}
+	for _, label := range remove {
+		operations = append(operations, map[string]string{"remove": label})
+	}
+
+	requestBody := map[string]interface{}{
+		"update": map[string]interface{}{"labels": operations},
+	}
+
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp, body)
+	}
+
+	return nil
+}
+
+// labelsPage represents one page of the labels API
+type labelsPage struct {
+	StartAt    int      `json:"startAt"`
+	MaxResults int      `json:"maxResults"`
+	Total      int      `json:"total"`
+	IsLast     bool     `json:"isLast"`
+	Values     []string `json:"values"`
+}
+
+// GetLabels retrieves every label in use on the site. Labels are not scoped
+// to projects, so the list can be long; it is fetched 1000 labels per request.
+func (c *Client) GetLabels() ([]string, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/label", c.baseURL)
+
+	var labels []string
+	startAt := 0
+	maxResults := 1000 // Maximum page size of the labels API
+
+	for {
+		params := url.Values{}
+		params.Add("startAt", fmt.Sprintf("%d", startAt))
+		params.Add("maxResults", fmt.Sprintf("%d", maxResults))
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", endpoint, params.Encode()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.doConditional(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, newAPIError(resp, body)
+		}
+
+		var page labelsPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		labels = append(labels, page.Values...)
+
+		startAt += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 {
+			break
+		}
+	}
+
+	return labels, nil
+}
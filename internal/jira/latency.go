@@ -0,0 +1,110 @@
+package jira
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EndpointStats summarizes the latency of one API endpoint. Percentiles use
+// the nearest rank and are encoded in nanoseconds.
+type EndpointStats struct {
+	// Endpoint is the method and path, with issue keys and IDs replaced by
+	// {id}, e.g. "GET /rest/api/3/issue/{id}"
+	Endpoint string        `json:"endpoint"`
+	Requests int           `json:"requests"`
+	P50      time.Duration `json:"p50Nanos"`
+	P90      time.Duration `json:"p90Nanos"`
+	P99      time.Duration `json:"p99Nanos"`
+	Max      time.Duration `json:"maxNanos"`
+}
+
+// SetSlowRequestThreshold logs every request taking at least d, with its
+// request ID (0 disables the log)
+func (c *Client) SetSlowRequestThreshold(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slowRequest = d
+}
+
+// observe records how long a request took and logs it if it was slow
+func (c *Client) observe(req *http.Request, resp *http.Response, elapsed time.Duration) {
+	endpoint := endpointName(req)
+	c.mu.Lock()
+	if c.latencies == nil {
+		c.latencies = make(map[string][]time.Duration)
+	}
+	c.latencies[endpoint] = append(c.latencies[endpoint], elapsed)
+	slow := c.slowRequest > 0 && elapsed >= c.slowRequest
+	c.mu.Unlock()
+
+	if !slow {
+		return
+	}
+	outcome := "no response"
+	if resp != nil {
+		outcome = "status " + resp.Status
+		for _, name := range []string{"X-Arequestid", "Atl-Traceid"} {
+			if id := resp.Header.Get(name); id != "" {
+				outcome += ", " + name + "=" + id
+				break
+			}
+		}
+	}
+	c.logger.Warnf("Slow request: %s took %s (%s)", endpoint, elapsed.Round(time.Millisecond), outcome)
+}
+
+// endpointStats returns the latency summary of every endpoint called, by
+// endpoint. The caller holds c.mu.
+func (c *Client) endpointStats() []EndpointStats {
+	stats := make([]EndpointStats, 0, len(c.latencies))
+	for endpoint, latencies := range c.latencies {
+		sorted := append([]time.Duration(nil), latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats = append(stats, EndpointStats{
+			Endpoint: endpoint,
+			Requests: len(sorted),
+			P50:      percentile(sorted, 50),
+			P90:      percentile(sorted, 90),
+			P99:      percentile(sorted, 99),
+			Max:      sorted[len(sorted)-1],
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Endpoint < stats[j].Endpoint })
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// endpointName groups requests by method and path, replacing path segments
+// that identify an issue so that per-issue calls share one endpoint
+func endpointName(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		// The segment after /rest/api is the API version
+		if i > 0 && segments[i-1] == "api" {
+			continue
+		}
+		if ValidKey(segment) || isNumeric(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
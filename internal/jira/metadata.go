@@ -0,0 +1,117 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// SetMetadataCache keeps project and label metadata in dir and revalidates
+// it with If-None-Match, so that runs on a schedule do not download
+// unchanged metadata again. Responses without an ETag are not cached. The
+// revalidation itself still counts towards the API call budget ("" disables
+// the cache).
+func (c *Client) SetMetadataCache(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metadataCache = dir
+}
+
+// cachedResponse is a metadata response stored with its ETag
+type cachedResponse struct {
+	URL  string `json:"url"`
+	ETag string `json:"etag"`
+	Body []byte `json:"body"`
+}
+
+// doConditional is do for metadata GETs, answering from the metadata cache
+// when Jira reports the cached response as unchanged
+func (c *Client) doConditional(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	dir := c.metadataCache
+	c.mu.Unlock()
+	if dir == "" || req.Method != http.MethodGet {
+		return c.do(req)
+	}
+
+	// Responses depend on the user's permissions, so the user is part of the key
+	sum := sha256.Sum256([]byte(c.identity() + " " + req.URL.String()))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+	cached := readCachedResponse(path)
+	if cached != nil {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.mu.Lock()
+		c.stats.NotModified++
+		c.mu.Unlock()
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.ContentLength = int64(len(cached.Body))
+		resp.Body = io.NopCloser(bytes.NewReader(cached.Body))
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		entry := cachedResponse{URL: req.URL.String(), ETag: resp.Header.Get("ETag"), Body: body}
+		if err := writeCachedResponse(path, entry); err != nil {
+			c.logger.Warnf("Failed to cache %s: %v", req.URL.Path, err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// readCachedResponse returns the response cached at path, or nil if there
+// is none or it cannot be read
+func readCachedResponse(path string) *cachedResponse {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cachedResponse
+	if err := json.Unmarshal(data, &entry); err != nil || entry.ETag == "" {
+		return nil
+	}
+	return &entry
+}
+
+// writeCachedResponse replaces the response cached at path atomically, so
+// that concurrent runs never read a truncated entry
+func writeCachedResponse(path string, entry cachedResponse) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metadata-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
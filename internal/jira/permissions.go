@@ -0,0 +1,65 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Permission keys relevant to archiving
+const (
+	PermissionAdminister         = "ADMINISTER"
+	PermissionAdministerProjects = "ADMINISTER_PROJECTS"
+	PermissionBrowseProjects     = "BROWSE_PROJECTS"
+)
+
+// myPermissionsResponse represents the response of the my permissions API
+type myPermissionsResponse struct {
+	Permissions map[string]struct {
+		HavePermission bool `json:"havePermission"`
+	} `json:"permissions"`
+}
+
+// GetMyPermissions reports which of the given permissions the current user
+// holds in a project
+func (c *Client) GetMyPermissions(projectKey string, permissions ...string) (map[string]bool, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/mypermissions", c.baseURL)
+
+	params := url.Values{}
+	params.Add("projectKey", projectKey)
+	for _, p := range permissions {
+		params.Add("permissions", p)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", endpoint, params.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, body)
+	}
+
+	var result myPermissionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	granted := make(map[string]bool, len(result.Permissions))
+	for key, p := range result.Permissions {
+		granted[key] = p.HavePermission
+	}
+	return granted, nil
+}
@@ -0,0 +1,68 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// projectsPage represents one page of the project search API
+type projectsPage struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	IsLast     bool      `json:"isLast"`
+	Values     []Project `json:"values"`
+}
+
+// GetProjects retrieves every project the current user can browse
+func (c *Client) GetProjects() ([]Project, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/project/search", c.baseURL)
+
+	var projects []Project
+	startAt := 0
+	maxResults := 50 // Maximum page size of the project search API
+
+	for {
+		params := url.Values{}
+		params.Add("startAt", fmt.Sprintf("%d", startAt))
+		params.Add("maxResults", fmt.Sprintf("%d", maxResults))
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", endpoint, params.Encode()), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.authorize(req)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.doConditional(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, newAPIError(resp, body)
+		}
+
+		var page projectsPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		projects = append(projects, page.Values...)
+
+		startAt += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 {
+			break
+		}
+	}
+
+	return projects, nil
+}
@@ -0,0 +1,83 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// SetIssueProperty stores value as JSON in the issue entity property propertyKey
+func (c *Client) SetIssueProperty(issueIDOrKey, propertyKey string, value interface{}) error {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s/properties/%s", c.baseURL,
+		url.PathEscape(issueIDOrKey), url.PathEscape(propertyKey))
+
+	jsonBody, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp, body)
+	}
+
+	return nil
+}
+
+// GetIssueProperty decodes the issue entity property propertyKey into value.
+// It reports false if the issue has no such property.
+func (c *Client) GetIssueProperty(issueIDOrKey, propertyKey string, value interface{}) (bool, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/3/issue/%s/properties/%s", c.baseURL,
+		url.PathEscape(issueIDOrKey), url.PathEscape(propertyKey))
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, newAPIError(resp, body)
+	}
+
+	var property struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&property); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(property.Value, value); err != nil {
+		return false, fmt.Errorf("failed to decode property %s: %w", propertyKey, err)
+	}
+	return true, nil
+}
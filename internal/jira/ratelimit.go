@@ -0,0 +1,24 @@
+package jira
+
+import (
+	"net/http"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+// pace waits for the shared transport's rate limit for the Jira host, set
+// with MAX_REQUESTS_PER_SECOND (or its alias JIRA_RATE_LIMIT_RPS), and
+// counts the time waited. The returned request is marked so that the
+// transport does not take a second token for it.
+func (c *Client) pace(req *http.Request) (*http.Request, error) {
+	ctx, waited, err := transport.Wait(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	if waited > 0 {
+		c.mu.Lock()
+		c.stats.RateLimitWait += waited
+		c.mu.Unlock()
+	}
+	return req.WithContext(ctx), nil
+}
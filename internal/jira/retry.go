@@ -0,0 +1,130 @@
+package jira
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	// DefaultRetryBaseDelay is the first backoff when no Retry-After is given
+	DefaultRetryBaseDelay = time.Second
+	// DefaultRetryJitter is the default fraction of random delay added to
+	// each backoff
+	DefaultRetryJitter = 0.2
+	maxRetryDelay      = 30 * time.Second
+)
+
+// RequestStats summarizes the requests a client has sent
+type RequestStats struct {
+	Requests    int `json:"requests"`
+	Retries     int `json:"retries"`
+	RateLimited int `json:"rateLimited"`
+	// Backoff is encoded in nanoseconds
+	Backoff time.Duration `json:"backoffNanos"`
+	// RateLimitWait is the time spent pacing requests to MAX_REQUESTS_PER_SECOND,
+	// encoded in nanoseconds
+	RateLimitWait time.Duration `json:"rateLimitWaitNanos"`
+	// Hedged counts searches that sent a second request after
+	// SEARCH_HEDGE_MS; HedgeWins those the second request answered first
+	Hedged    int `json:"hedged"`
+	HedgeWins int `json:"hedgeWins"`
+	// NotModified counts metadata requests answered from METADATA_CACHE_DIR
+	// after Jira reported them unchanged (304)
+	NotModified int `json:"notModified"`
+	// Endpoints breaks the request latency down per endpoint
+	Endpoints []EndpointStats `json:"endpoints,omitempty"`
+}
+
+// SetMaxRetries sets how often a throttled or temporarily failing request
+// is retried (0 disables retries)
+func (c *Client) SetMaxRetries(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxRetries = n
+}
+
+// SetRetryBackoff sets the delay before the first retry, which doubles on
+// every further attempt, and the fraction of random delay (0 to 1) added
+// to each wait so that concurrent workers do not retry in lockstep. A
+// Retry-After header takes the place of the exponential delay.
+func (c *Client) SetRetryBackoff(base time.Duration, jitter float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryBaseDelay = base
+	c.retryJitter = jitter
+}
+
+// Stats returns the request statistics collected so far
+func (c *Client) Stats() RequestStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Requests = c.calls
+	stats.Endpoints = c.endpointStats()
+	return stats
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transient reports whether a failed request may succeed when sent again:
+// a timeout or a dropped connection, but not a cancelled context. Only
+// idempotent methods are retried, since the server may have acted on the
+// first attempt.
+func transient(req *http.Request, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryDelay honors Retry-After (seconds or an HTTP date) and otherwise
+// backs off exponentially from base. resp is nil after a network error.
+func retryDelay(resp *http.Response, attempt int, base time.Duration, jitter float64, now time.Time) time.Duration {
+	wait := min(base<<attempt, maxRetryDelay)
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After"), now); ok {
+			wait = min(after, maxRetryDelay)
+		}
+	}
+	if jitter > 0 {
+		wait += time.Duration(rand.Float64() * jitter * float64(wait))
+	}
+	return wait
+}
+
+// retryAfter parses a Retry-After header value
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
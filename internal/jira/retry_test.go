@@ -0,0 +1,169 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 12, 20, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"Fri, 20 Dec 2024 09:00:30 GMT", 30 * time.Second, true},
+		// A date in the past means retry now
+		{"Fri, 20 Dec 2024 08:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Now()
+	withRetryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", nil, 0, time.Second},
+		{"doubles per attempt", nil, 3, 8 * time.Second},
+		{"capped", nil, 10, maxRetryDelay},
+		{"Retry-After replaces the backoff", withRetryAfter("2"), 3, 2 * time.Second},
+		{"Retry-After is capped", withRetryAfter("3600"), 0, maxRetryDelay},
+		{"invalid Retry-After is ignored", withRetryAfter("soon"), 1, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.resp, tt.attempt, time.Second, 0, now); got != tt.want {
+			t.Errorf("%s: retryDelay() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	for range 100 {
+		if got := retryDelay(nil, 0, time.Second, 0.5, now); got < time.Second || got > 1500*time.Millisecond {
+			t.Fatalf("retryDelay() with 50%% jitter = %s, want between 1s and 1.5s", got)
+		}
+	}
+}
+
+func newTestClient(url string) *Client {
+	client := NewClient(url, "user@example.com", "token")
+	client.SetRetryBackoff(time.Millisecond, 0)
+	client.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return client
+}
+
+func TestRetriesThrottledRequests(t *testing.T) {
+	var calls atomic.Int32
+	var bodies [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ArchiveRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		bodies = append(bodies, req.IssueIdsOrKeys)
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"errorMessages":["rate limited"]}`, http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(ArchiveResponse{NumberOfIssuesUpdated: len(req.IssueIdsOrKeys)})
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp, err := client.ArchiveIssues([]string{"PROJ-1", "PROJ-2"})
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	if resp.NumberOfIssuesUpdated != 2 {
+		t.Errorf("NumberOfIssuesUpdated = %d, want 2", resp.NumberOfIssuesUpdated)
+	}
+	// The request body is sent again on every retry
+	for i, keys := range bodies {
+		if !slices.Equal(keys, []string{"PROJ-1", "PROJ-2"}) {
+			t.Errorf("attempt %d sent %v, want both keys", i+1, keys)
+		}
+	}
+
+	stats := client.Stats()
+	if stats.Requests != 3 || stats.Retries != 2 || stats.RateLimited != 1 {
+		t.Errorf("stats = %+v, want 3 requests, 2 retries and 1 rate limited", stats)
+	}
+}
+
+func TestRetriesStopAtMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetMaxRetries(2)
+	if _, err := client.GetMyPermissions("PROJ", PermissionAdminister); err == nil {
+		t.Fatal("GetMyPermissions() succeeded, want the last 503")
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("sent %d requests, want the first and 2 retries", n)
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"errorMessages":["bad request"]}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	if _, err := client.GetMyPermissions("PROJ", PermissionAdminister); err == nil {
+		t.Fatal("GetMyPermissions() succeeded, want the 400")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestMaxAPICallsIncludesRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetMaxAPICalls(2)
+	_, err := client.GetMyPermissions("PROJ", PermissionAdminister)
+	if !errors.Is(err, ErrAPIBudgetExhausted) {
+		t.Errorf("GetMyPermissions() error = %v, want ErrAPIBudgetExhausted", err)
+	}
+	if n := client.APICalls(); n != 2 {
+		t.Errorf("APICalls() = %d, want 2", n)
+	}
+}
@@ -0,0 +1,112 @@
+package jira
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// searchFields are the issue fields every search requests, because
+// selection, preflight and reports rely on them
+var searchFields = []string{"summary", "status", "updated", "assignee", "project", "issuetype", "security", "labels"}
+
+// SetSearchFields requests additional issue fields in searches. Their
+// values are available through IssueFields.Extra.
+func (c *Client) SetSearchFields(fields []string) {
+	c.extraFields = fields
+}
+
+// SetSearchExpand passes expand options, such as changelog or
+// renderedFields, to searches. The expanded data is available through
+// Issue.Expanded.
+func (c *Client) SetSearchExpand(expand []string) {
+	c.expand = expand
+}
+
+// SetSearchPageHook calls hook after every page GetAllIssues fetches, with
+// the query, the token of the next page ("" after the last page) and the
+// number of issues fetched so far
+func (c *Client) SetSearchPageHook(hook func(jql, nextPageToken string, fetched int)) {
+	c.pageHook = hook
+}
+
+// searchFieldList returns the fields parameter of searches
+func (c *Client) searchFieldList() string {
+	fields := append([]string(nil), searchFields...)
+	for _, f := range c.extraFields {
+		if !containsFold(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// issueKeys are the members of an issue decoded into Issue itself
+var issueKeys = []string{"id", "key", "self", "fields", "expand"}
+
+// UnmarshalJSON decodes an issue, keeping expanded members such as
+// changelog in Expanded
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	type plain Issue
+	if err := json.Unmarshal(data, (*plain)(i)); err != nil {
+		return err
+	}
+	expanded, err := unknownMembers(data, issueKeys)
+	if err != nil {
+		return err
+	}
+	i.Expanded = expanded
+	return nil
+}
+
+// issueFieldKeys are the fields decoded into IssueFields itself
+var issueFieldKeys = []string{"summary", "project", "issuetype", "labels", "status", "updated", "assignee", "archiveddate", "archivedby", "security", "subtasks"}
+
+// UnmarshalJSON decodes issue fields, keeping any field without a struct
+// member in Extra
+func (f *IssueFields) UnmarshalJSON(data []byte) error {
+	type plain IssueFields
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	extra, err := unknownMembers(data, issueFieldKeys)
+	if err != nil {
+		return err
+	}
+	f.Extra = extra
+	return nil
+}
+
+// Field decodes the extra field name into v. It reports false if the
+// field was not returned or is null.
+func (f IssueFields) Field(name string, v any) (bool, error) {
+	raw, ok := f.Extra[name]
+	if !ok || string(raw) == "null" {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// unknownMembers returns the members of a JSON object not named in known,
+// or nil if there are none
+func unknownMembers(data []byte, known []string) (map[string]json.RawMessage, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		delete(members, k)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	return members, nil
+}
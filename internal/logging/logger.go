@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// level is the minimum level written to the standard logger
+var level slog.LevelVar
+
+// SetLevel sets the minimum level of messages written to the standard
+// logger. An injected slog.Logger filters by its own handler instead.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel parses debug, info, warn or error, case-insensitively
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// Logger writes printf-style messages to an injected slog.Logger or, if
+// none was given, to the standard log package. The zero value uses the
+// standard logger, which keeps the command's log format unchanged.
+type Logger struct {
+	slog *slog.Logger
+}
+
+// NewLogger returns a Logger writing to l. A nil l uses the standard logger.
+func NewLogger(l *slog.Logger) Logger {
+	return Logger{slog: l}
+}
+
+// With returns a Logger adding args as attributes to every record. It has
+// no effect when writing to the standard logger.
+func (l Logger) With(args ...any) Logger {
+	if l.slog == nil {
+		return l
+	}
+	return Logger{slog: l.slog.With(args...)}
+}
+
+// Slog returns the injected slog.Logger, or nil
+func (l Logger) Slog() *slog.Logger {
+	return l.slog
+}
+
+// Debugf logs detail only needed to debug a run, such as every request
+func (l Logger) Debugf(format string, args ...any) {
+	l.output(slog.LevelDebug, format, args...)
+}
+
+// Infof logs a routine message
+func (l Logger) Infof(format string, args ...any) {
+	l.output(slog.LevelInfo, format, args...)
+}
+
+// Warnf logs a failure that does not stop the run
+func (l Logger) Warnf(format string, args ...any) {
+	l.output(slog.LevelWarn, format, args...)
+}
+
+// Errorf logs a failure that stops the run or loses its result
+func (l Logger) Errorf(format string, args ...any) {
+	l.output(slog.LevelError, format, args...)
+}
+
+// Fatalf logs at error level and exits with status 1 without running
+// deferred functions, like log.Fatalf
+func (l Logger) Fatalf(format string, args ...any) {
+	l.output(slog.LevelError, format, args...)
+	os.Exit(1)
+}
+
+func (l Logger) output(lvl slog.Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if l.slog == nil {
+		if lvl < level.Level() {
+			return
+		}
+		// Skip output and the level method so Lshortfile names the caller
+		log.Output(3, lvl.String()+" "+msg)
+		return
+	}
+	ctx := context.Background()
+	if !l.slog.Enabled(ctx, lvl) {
+		return
+	}
+	// Attribute the record to the caller rather than to this wrapper
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), lvl, strings.TrimSuffix(msg, "\n"), pcs[0])
+	_ = l.slog.Handler().Handle(ctx, record)
+}
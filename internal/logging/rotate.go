@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102-150405.000"
+
+// RotatingFile is an io.Writer that rotates the underlying file once it
+// exceeds a maximum size, keeping a bounded number of timestamped backups
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens (or creates) path for appending. A maxSize of 0
+// disables rotation; maxAge and maxBackups of 0 keep backups forever.
+func NewRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the current file, rotating first if p would exceed the size limit
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file to a timestamped backup and starts a new one
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	backup := r.path + "." + time.Now().Format(backupTimeFormat)
+	if err := os.Rename(r.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.prune()
+	return nil
+}
+
+// prune removes backups beyond the configured count or age
+func (r *RotatingFile) prune() {
+	backups, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return
+	}
+
+	// Only consider files produced by rotate
+	var matched []string
+	for _, b := range backups {
+		suffix := strings.TrimPrefix(b, r.path+".")
+		if _, err := time.Parse(backupTimeFormat, suffix); err == nil {
+			matched = append(matched, b)
+		}
+	}
+
+	// Newest first; the timestamp format sorts lexically
+	sort.Sort(sort.Reverse(sort.StringSlice(matched)))
+
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, b := range matched {
+		expired := false
+		if r.maxBackups > 0 && i >= r.maxBackups {
+			expired = true
+		}
+		if r.maxAge > 0 {
+			if info, err := os.Stat(b); err == nil && info.ModTime().Before(cutoff) {
+				expired = true
+			}
+		}
+		if expired {
+			os.Remove(b)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"net"
+	"strings"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// NewSystemWriter returns a writer sending log lines to syslog or journald.
+// For syslog, an empty address uses the local daemon; otherwise it is
+// "udp://host:514" or "tcp://host:514".
+func NewSystemWriter(target, address, tag string) (io.WriteCloser, error) {
+	switch target {
+	case "syslog":
+		network, raddr := "", ""
+		if address != "" {
+			var ok bool
+			network, raddr, ok = strings.Cut(address, "://")
+			if !ok {
+				return nil, fmt.Errorf("invalid syslog address %q (expected udp://host:port or tcp://host:port)", address)
+			}
+		}
+		w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		return &syslogWriter{w: w}, nil
+	case "journald":
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to journald: %w", err)
+		}
+		return &journaldWriter{conn: conn, tag: tag}, nil
+	default:
+		return nil, fmt.Errorf("unknown system log target %q (expected syslog or journald)", target)
+	}
+}
+
+// lineLevel returns the level of a standard logger line, written as
+// "date time file.go:N: LEVEL message". Lines without a level token, such
+// as those of plain log.Printf calls, are info.
+func lineLevel(line []byte) slog.Level {
+	fields := bytes.Fields(line)
+	for _, field := range fields[:min(len(fields), 4)] {
+		if !bytes.Equal(field, bytes.ToUpper(field)) {
+			continue
+		}
+		if lvl, err := ParseLevel(string(field)); err == nil {
+			return lvl
+		}
+	}
+	return slog.LevelInfo
+}
+
+// syslogWriter sends each log line at the syslog severity of its level
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	message := string(p)
+	var err error
+	switch lvl := lineLevel(p); {
+	case lvl >= slog.LevelError:
+		err = s.w.Err(message)
+	case lvl >= slog.LevelWarn:
+		err = s.w.Warning(message)
+	case lvl >= slog.LevelInfo:
+		err = s.w.Info(message)
+	default:
+		err = s.w.Debug(message)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+// journaldPriority returns the syslog priority journald records for lvl
+func journaldPriority(lvl slog.Level) int {
+	switch {
+	case lvl >= slog.LevelError:
+		return int(syslog.LOG_ERR)
+	case lvl >= slog.LevelWarn:
+		return int(syslog.LOG_WARNING)
+	case lvl >= slog.LevelInfo:
+		return int(syslog.LOG_INFO)
+	default:
+		return int(syslog.LOG_DEBUG)
+	}
+}
+
+// journaldWriter sends each log line as a journal entry over the native protocol
+type journaldWriter struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+func (j *journaldWriter) Write(p []byte) (int, error) {
+	message := bytes.TrimRight(p, "\n")
+
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "PRIORITY=%d\n", journaldPriority(lineLevel(message)))
+	entry.WriteString("SYSLOG_IDENTIFIER=" + j.tag + "\n")
+	if bytes.IndexByte(message, '\n') >= 0 {
+		// Multi-line values use the length-prefixed binary form
+		entry.WriteString("MESSAGE\n")
+		binary.Write(&entry, binary.LittleEndian, uint64(len(message)))
+		entry.Write(message)
+		entry.WriteByte('\n')
+	} else {
+		entry.WriteString("MESSAGE=")
+		entry.Write(message)
+		entry.WriteByte('\n')
+	}
+
+	if _, err := j.conn.Write(entry.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (j *journaldWriter) Close() error {
+	return j.conn.Close()
+}
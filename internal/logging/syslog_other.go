@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"fmt"
+	"io"
+)
+
+// NewSystemWriter is not supported on this platform
+func NewSystemWriter(target, _, _ string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("system log target %q is not supported on this platform", target)
+}
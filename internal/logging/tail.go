@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Tail is an io.Writer that keeps the most recent bytes written to it, so
+// the end of a run's log can be attached to a support bundle
+type Tail struct {
+	max int
+
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+}
+
+// NewTail returns a Tail keeping at most max bytes
+func NewTail(max int) *Tail {
+	return &Tail{max: max}
+}
+
+// Write appends p, discarding the oldest bytes beyond the limit
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the kept bytes. If older output was discarded,
+// the partial first line is dropped too.
+func (t *Tail) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := bytes.Clone(t.buf)
+	if t.truncated {
+		if i := bytes.IndexByte(out, '\n'); i >= 0 {
+			out = out[i+1:]
+		}
+	}
+	return out
+}
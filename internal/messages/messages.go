@@ -0,0 +1,138 @@
+// Package messages renders the summaries, reports and alert texts shown to
+// people. Built-in templates exist per locale and can be overridden by files
+// in a template directory, so wording, language and report layout can change
+// without a rebuild. Templates whose name ends in .html are parsed with
+// html/template and escape their data.
+package messages
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Template names
+const (
+	Summary       = "summary"
+	Digest        = "digest"
+	AlertAborted  = "alert_aborted"
+	AlertFailures = "alert_failures"
+
+	ReportMarkdown = "report.md"
+	ReportHTML     = "report.html"
+)
+
+// names lists every template, for Check
+var names = []string{Summary, Digest, AlertAborted, AlertFailures, ReportMarkdown, ReportHTML}
+
+// DefaultLocale is used when a template is missing for the requested locale
+const DefaultLocale = "en"
+
+//go:embed templates
+var builtin embed.FS
+
+// funcs are available to every template
+var funcs = template.FuncMap{
+	"rule": func() string { return strings.Repeat("=", 50) },
+	"join": strings.Join,
+}
+
+// Catalog renders the templates of one locale
+type Catalog struct {
+	locale string
+	dir    string
+	cache  map[string]executor
+}
+
+// executor is implemented by text and HTML templates
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// New returns a catalog for locale. Templates are looked up as
+// dir/<locale>/<name>.tmpl first, then among the built-in templates of
+// locale and finally of DefaultLocale. An empty dir uses built-ins only.
+func New(dir, locale string) *Catalog {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	return &Catalog{locale: locale, dir: dir, cache: make(map[string]executor)}
+}
+
+// Render executes the named template with data
+func (c *Catalog) Render(name string, data any) (string, error) {
+	tmpl, err := c.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// Check parses every template so a broken override fails before a run
+func (c *Catalog) Check() error {
+	for _, name := range names {
+		if _, err := c.lookup(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) lookup(name string) (executor, error) {
+	if tmpl, ok := c.cache[name]; ok {
+		return tmpl, nil
+	}
+
+	text, source, err := c.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl executor
+	if strings.HasSuffix(name, ".html") {
+		tmpl, err = htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	} else {
+		tmpl, err = template.New(name).Funcs(funcs).Parse(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", source, err)
+	}
+	c.cache[name] = tmpl
+	return tmpl, nil
+}
+
+// read returns the text of the first template found and where it came from
+func (c *Catalog) read(name string) (string, string, error) {
+	file := name + ".tmpl"
+
+	if c.dir != "" {
+		path := filepath.Join(c.dir, c.locale, file)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return string(data), path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", fmt.Errorf("failed to read template: %w", err)
+		}
+	}
+
+	for _, locale := range []string{c.locale, DefaultLocale} {
+		path := "templates/" + locale + "/" + file
+		if data, err := builtin.ReadFile(path); err == nil {
+			return string(data), path, nil
+		}
+	}
+	return "", "", fmt.Errorf("no %s template for locale %s", name, c.locale)
+}
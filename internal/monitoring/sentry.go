@@ -0,0 +1,62 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const flushTimeout = 5 * time.Second
+
+// Reporter sends panics and run-level failures to Sentry.
+// A nil Reporter is valid and reports nothing.
+type Reporter struct{}
+
+// NewReporter initializes Sentry. It returns a nil Reporter when dsn is empty.
+func NewReporter(dsn, environment string) (*Reporter, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Sentry: %w", err)
+	}
+
+	return &Reporter{}, nil
+}
+
+// SetRunContext attaches run details to every subsequent report
+func (r *Reporter) SetRunContext(run map[string]interface{}) {
+	if r == nil {
+		return
+	}
+	sentry.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetContext("run", run)
+	})
+}
+
+// CaptureFailure reports a run-level failure and waits for delivery
+func (r *Reporter) CaptureFailure(err error) {
+	if r == nil {
+		return
+	}
+	sentry.CaptureException(err)
+	sentry.Flush(flushTimeout)
+}
+
+// Recover reports a panic and re-panics. It must be called with defer.
+func (r *Reporter) Recover() {
+	if r == nil {
+		return
+	}
+	if v := recover(); v != nil {
+		sentry.CurrentHub().Recover(v)
+		sentry.Flush(flushTimeout)
+		panic(v)
+	}
+}
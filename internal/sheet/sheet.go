@@ -0,0 +1,59 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Write writes rows (the first row being the header) to path, as XLSX when
+// the extension is .xlsx and as CSV otherwise
+func Write(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if isXLSX(path) {
+		err = writeXLSX(f, rows)
+	} else {
+		w := csv.NewWriter(f)
+		err = w.WriteAll(rows)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// Read reads all rows of a CSV file or of the first sheet of an XLSX file
+func Read(path string) ([][]string, error) {
+	if isXLSX(path) {
+		rows, err := readXLSX(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return rows, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return rows, nil
+}
+
+func isXLSX(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".xlsx")
+}
@@ -0,0 +1,107 @@
+package sheet
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	header := make([]string, 30)
+	for i := range header {
+		header[i] = "col" + strconv.Itoa(i)
+	}
+	rows := [][]string{
+		header,
+		{"PROJ-1", `Fix <b> & "quotes"`, "  spaced  ", "", "アーカイブ"},
+		{"PROJ-2", "line\nbreak"},
+	}
+	for _, name := range []string{"issues.xlsx", "issues.XLSX", "issues.csv"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := Write(path, rows); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			got, err := Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, rows) {
+				t.Errorf("Read() = %q, want %q", got, rows)
+			}
+		})
+	}
+}
+
+func TestReadXLSXSharedStringsAndSparseCells(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.xlsx")
+	writeZip(t, path, map[string]string{
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Key</t></si><si><r><t>Sum</t></r><r><t>mary</t></r></si><si><t>PROJ-7</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="AB2"><v>42</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := [][]string{{"Key", "", "Summary"}, make([]string, 28)}
+	want[1][0], want[1][27] = "PROJ-7", "42"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no worksheet": {"xl/workbook.xml": "<workbook/>"},
+		"bad shared string": {"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>3</v></c></row></sheetData></worksheet>`},
+	}
+	for name, parts := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bad.xlsx")
+			writeZip(t, path, parts)
+			if rows, err := Read(path); err == nil {
+				t.Errorf("Read() = %q, want an error", rows)
+			}
+		})
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != name {
+			t.Errorf("columnName(%d) = %s, want %s", index, got, name)
+		}
+		if got := columnIndex(name + "12"); got != index {
+			t.Errorf("columnIndex(%s12) = %d, want %d", name, got, index)
+		}
+	}
+}
+
+func writeZip(t *testing.T, path string, parts map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
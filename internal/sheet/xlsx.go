@@ -0,0 +1,204 @@
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Minimal SpreadsheetML parts for a single-sheet workbook
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Issues" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+)
+
+// writeXLSX writes rows as a single worksheet using inline strings
+func writeXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(c), r+1)
+			xml.EscapeText(&b, []byte(value))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+
+	if _, err := io.WriteString(sheet, b.String()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// columnName converts a zero-based column index to its letter name (0 -> A)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column index
+func columnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A') + 1
+	}
+	return index - 1
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+// readXLSX reads the first worksheet, resolving shared and inline strings
+func readXLSX(path string) ([][]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var shared []string
+	var sheetFile *zip.File
+	for _, f := range zr.File {
+		switch f.Name {
+		case "xl/sharedStrings.xml":
+			var sst xlsxSharedStrings
+			if err := decodeZipXML(f, &sst); err != nil {
+				return nil, err
+			}
+			for _, item := range sst.Items {
+				text := item.Text
+				for _, run := range item.Runs {
+					text += run.Text
+				}
+				shared = append(shared, text)
+			}
+		case "xl/worksheets/sheet1.xml":
+			sheetFile = f
+		}
+	}
+	if sheetFile == nil {
+		return nil, fmt.Errorf("workbook has no first worksheet")
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(sheetFile, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				n, err := strconv.Atoi(cell.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("invalid shared string reference %q", cell.Value)
+				}
+				value = shared[n]
+			case "inlineStr":
+				value = cell.Inline.Text
+				for _, run := range cell.Inline.Runs {
+					value += run.Text
+				}
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
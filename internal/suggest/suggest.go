@@ -0,0 +1,75 @@
+// Package suggest finds likely intended values for misspelled names
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Closest returns up to n candidates within a small edit distance of name,
+// nearest first. Comparison ignores case; names shorter than four
+// characters only match candidates one edit away.
+func Closest(name string, candidates []string, n int) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+
+	target := strings.ToLower(name)
+	limit := max(1, len([]rune(target))/3)
+
+	var matches []match
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		d := distance(target, strings.ToLower(candidate))
+		if d <= limit {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].value < matches[j].value
+	})
+
+	var closest []string
+	for i := 0; i < len(matches) && i < n; i++ {
+		closest = append(closest, matches[i].value)
+	}
+	return closest
+}
+
+// distance is the Damerau-Levenshtein (optimal string alignment) distance,
+// so a swapped pair of letters such as PRJO for PROJ counts as one edit
+func distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -0,0 +1,153 @@
+// Package transport provides the HTTP transport shared by every subsystem
+// that calls out: the Jira client, notifiers, the freeze calendar and the
+// warehouse sinks. Sharing one transport pools connections per host and
+// applies one request rate limit per host, so features that each stay
+// within a limit cannot exceed it together.
+package transport
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxIdleConnsPerHost keeps connections open for every archive worker
+// instead of net/http's default of 2
+const maxIdleConnsPerHost = 32
+
+// Shared is the transport used by the clients returned by Client
+var Shared = newLimitedTransport()
+
+// Client returns an HTTP client using the shared transport with the given
+// overall request timeout
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Shared, Timeout: timeout}
+}
+
+// SetRateLimit limits the requests sent to each host through the shared
+// transport to perSecond, allowing bursts of up to one second's worth. Zero
+// or less removes the limit.
+func SetRateLimit(perSecond float64) {
+	Shared.setRate(perSecond)
+}
+
+// pacedKey marks the context of a request that already waited in Wait
+type pacedKey struct{}
+
+// Wait waits for host's limiter, as the shared transport would before
+// sending a request, and returns how long it waited. Requests made with the
+// returned context are not delayed again by the shared transport, so
+// clients can account for their time spent rate limited without being
+// limited twice.
+func Wait(ctx context.Context, host string) (context.Context, time.Duration, error) {
+	var waited time.Duration
+	if l := Shared.limiter(host); l != nil {
+		var err error
+		if waited, err = l.Wait(ctx); err != nil {
+			return ctx, 0, err
+		}
+	}
+	return context.WithValue(ctx, pacedKey{}, true), waited, nil
+}
+
+// limitedTransport waits for the host's limiter before each request
+type limitedTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	rate     float64
+	limiters map[string]*Limiter
+}
+
+func newLimitedTransport() *limitedTransport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return &limitedTransport{base: base, limiters: make(map[string]*Limiter)}
+}
+
+func (t *limitedTransport) setRate(perSecond float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = perSecond
+	t.limiters = make(map[string]*Limiter)
+}
+
+// limiter returns the host's limiter, or nil without a rate limit
+func (t *limitedTransport) limiter(host string) *Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rate <= 0 {
+		return nil
+	}
+	l, ok := t.limiters[host]
+	if !ok {
+		l = NewLimiter(t.rate)
+		t.limiters[host] = l
+	}
+	return l
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(pacedKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+	if l := t.limiter(req.URL.Host); l != nil {
+		if _, err := l.Wait(req.Context()); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Limiter is a token bucket allowing perSecond requests with bursts of up
+// to one second's worth. It is safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a full limiter; perSecond must be positive
+func NewLimiter(perSecond float64) *Limiter {
+	return &Limiter{rate: perSecond, tokens: burst(perSecond), last: time.Now()}
+}
+
+func burst(perSecond float64) float64 {
+	return max(perSecond, 1)
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, burst(l.rate))
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a request may be sent or ctx is done and returns how
+// long it waited
+func (l *Limiter) Wait(ctx context.Context) (time.Duration, error) {
+	delay := l.reserve(time.Now())
+	if delay == 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
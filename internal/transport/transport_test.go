@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWaitSharesTheTransportLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	SetRateLimit(2)
+	t.Cleanup(func() { SetRateLimit(0) })
+	client := Client(5 * time.Second)
+
+	get := func(ctx context.Context) time.Duration {
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return time.Since(start)
+	}
+
+	// Two tokens: one taken by Wait, one by a plain request
+	ctx, waited, err := Wait(context.Background(), u.Host)
+	if err != nil || waited != 0 {
+		t.Fatalf("Wait() = %s, %v; want no wait from a full bucket", waited, err)
+	}
+	if d := get(ctx); d > 250*time.Millisecond {
+		t.Errorf("paced request took %s, want it not to wait again", d)
+	}
+	get(context.Background())
+
+	// The bucket is empty now, for Wait as well as for the transport
+	if _, waited, _ := Wait(context.Background(), u.Host); waited < 250*time.Millisecond {
+		t.Errorf("Wait() after the burst waited %s, want about 500ms", waited)
+	}
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// opsgenieMaxMessage is Opsgenie's limit on the message, in characters
+	opsgenieMaxMessage = 130
+)
+
+// Alert describes an incident raised for a failed run
+type Alert struct {
+	Summary  string
+	Source   string
+	DedupKey string
+	Details  map[string]string
+}
+
+// Notifier delivers alerts to a notification target
+type Notifier interface {
+	Send(alert Alert) error
+}
+
+// Sender is the former name of Notifier.
+//
+// Deprecated: use Notifier.
+type Sender = Notifier
+
+// PagerDuty sends alerts through the PagerDuty Events API v2
+type PagerDuty struct {
+	routingKey string
+	httpClient *http.Client
+}
+
+// NewPagerDuty creates a PagerDuty notifier for an integration routing key
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		routingKey: routingKey,
+		httpClient: transport.Client(30 * time.Second),
+	}
+}
+
+// Send triggers a PagerDuty incident
+func (p *PagerDuty) Send(alert Alert) error {
+	body := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":        alert.Summary,
+			"source":         alert.Source,
+			"severity":       "error",
+			"custom_details": alert.Details,
+		},
+	}
+	if alert.DedupKey != "" {
+		body["dedup_key"] = alert.DedupKey
+	}
+
+	return postJSON(p.httpClient, pagerDutyEventsURL, nil, body)
+}
+
+// Opsgenie sends alerts through the Opsgenie Alert API
+type Opsgenie struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpsgenie creates an Opsgenie notifier. apiURL selects the region,
+// e.g. https://api.opsgenie.com or https://api.eu.opsgenie.com.
+func NewOpsgenie(apiURL, apiKey string) *Opsgenie {
+	return &Opsgenie{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		apiKey:     apiKey,
+		httpClient: transport.Client(30 * time.Second),
+	}
+}
+
+// Send creates an Opsgenie alert
+func (o *Opsgenie) Send(alert Alert) error {
+	body := map[string]interface{}{
+		"message":     truncate(alert.Summary, opsgenieMaxMessage),
+		"description": alert.Summary,
+		"source":      alert.Source,
+		"priority":    "P2",
+		"details":     alert.Details,
+	}
+	if alert.DedupKey != "" {
+		body["alias"] = alert.DedupKey
+	}
+
+	headers := map[string]string{"Authorization": "GenieKey " + o.apiKey}
+	return postJSON(o.httpClient, o.apiURL+"/v2/alerts", headers, body)
+}
+
+// truncate shortens s to at most n characters, cutting between runes so
+// that multi-byte text stays valid UTF-8
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// postJSON sends body as JSON and treats any 2xx status as success
+func postJSON(client *http.Client, endpoint string, headers map[string]string, body interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
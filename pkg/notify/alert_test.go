@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOpsgenieTruncatesMessageOnRuneBoundary(t *testing.T) {
+	var body struct {
+		Message     string `json:"message"`
+		Description string `json:"description"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	summary := strings.Repeat("アーカイブに失敗しました", 20)
+	if err := NewOpsgenie(server.URL, "key").Send(Alert{Summary: summary}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !utf8.ValidString(body.Message) {
+		t.Errorf("message %q is not valid UTF-8", body.Message)
+	}
+	if n := utf8.RuneCountInString(body.Message); n != opsgenieMaxMessage {
+		t.Errorf("message has %d characters, want %d", n, opsgenieMaxMessage)
+	}
+	if !strings.HasPrefix(summary, body.Message) || body.Description != summary {
+		t.Errorf("message = %q, description = %q; want a prefix and the full summary", body.Message, body.Description)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"archive", 4, "arch"},
+		{"日本語テキスト", 3, "日本語"},
+		{"", 3, ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Notifier)
+)
+
+// Register adds a notifier that receives every alert alongside the
+// notifiers enabled in the configuration. It is meant for programs that
+// embed the archiver and need their own targets, such as an internal chat
+// or ticketing system. Register panics if name is already registered or
+// notifier is nil.
+func Register(name string, notifier Notifier) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if notifier == nil {
+		panic("notify: Register notifier is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("notify: Register called twice for %q", name))
+	}
+	registry[name] = notifier
+}
+
+// Registered returns the registered notifiers, ordered by name
+func Registered() []Notifier {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	notifiers := make([]Notifier, 0, len(names))
+	for _, name := range names {
+		notifiers = append(notifiers, registry[name])
+	}
+	return notifiers
+}
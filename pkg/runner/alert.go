@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/messages"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/notify"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// Notifiers returns the notifiers enabled in the configuration followed by
+// any registered through notify.Register
+func Notifiers(cfg *Config) []notify.Notifier {
+	var notifiers []notify.Notifier
+	if cfg.PagerDutyRoutingKey != "" {
+		notifiers = append(notifiers, notify.NewPagerDuty(cfg.PagerDutyRoutingKey))
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		notifiers = append(notifiers, notify.NewOpsgenie(cfg.OpsgenieAPIURL, cfg.OpsgenieAPIKey))
+	}
+	return append(notifiers, notify.Registered()...)
+}
+
+// SendAlert delivers an alert to every notifier, logging delivery failures
+func SendAlert(notifiers []notify.Notifier, cfg *Config, summary string, details map[string]string) {
+	sendAlert(logging.Logger{}, notifiers, cfg, summary, details)
+}
+
+func sendAlert(logger logging.Logger, notifiers []notify.Notifier, cfg *Config, summary string, details map[string]string) {
+	if len(notifiers) == 0 {
+		return
+	}
+
+	alert := notify.Alert{
+		Summary:  summary,
+		Source:   cfg.JiraBaseURL,
+		DedupKey: fmt.Sprintf("jira-bulk-archive/%s/%s/%s", cfg.JiraProjectKey, cfg.ArchiveLabel, time.Now().Format("2006-01-02")),
+		Details:  details,
+	}
+	if alert.Details == nil {
+		alert.Details = map[string]string{}
+	}
+	alert.Details["project_key"] = cfg.JiraProjectKey
+	alert.Details["label"] = cfg.ArchiveLabel
+
+	for _, notifier := range notifiers {
+		if err := notifier.Send(alert); err != nil {
+			logger.Warnf("Failed to send alert: %v", err)
+		}
+	}
+}
+
+// alertRun alerts about the outcome of Run: a run that failed before
+// archiving, a run aborted by its safety checks, or failures above
+// ALERT_FAILURE_RATE. Dry runs, freezes, interruptions and a used-up API
+// call budget do not alert.
+func alertRun(cfg *Config, logger logging.Logger, result *RunResult, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, jira.ErrAPIBudgetExhausted) || errors.Is(err, worker.ErrStoppedOnBudget) {
+		return
+	}
+	if result == nil {
+		if err != nil {
+			sendAlert(logger, Notifiers(cfg), cfg, "Bulk archive run failed", map[string]string{"error": "Run failed: " + err.Error()})
+		}
+		return
+	}
+	if result.DryRun || !result.Archived() {
+		return
+	}
+
+	catalog := messages.New(cfg.TemplateDir, cfg.Locale)
+	if err != nil {
+		summary := renderLine(catalog, logger, messages.AlertAborted, map[string]any{
+			"Error": err.Error(),
+			"RunID": result.RunID,
+		}, "Bulk archive run aborted: "+err.Error())
+		sendAlert(logger, Notifiers(cfg), cfg, summary, AlertDetails(result))
+		return
+	}
+	if FailureRateExceeded(result.Total, result.Failed, cfg.AlertFailureRate) {
+		summary := renderLine(catalog, logger, messages.AlertFailures, result,
+			fmt.Sprintf("Bulk archive run: %d of %d issues failed", result.Failed, result.Total))
+		sendAlert(logger, Notifiers(cfg), cfg, summary, AlertDetails(result))
+	}
+}
+
+// renderLine renders a single-line message such as an alert summary,
+// falling back to the given text if the template fails
+func renderLine(catalog *messages.Catalog, logger logging.Logger, name string, data any, fallback string) string {
+	text, err := catalog.Render(name, data)
+	if err != nil {
+		logger.Warnf("Failed to render %s: %v", name, err)
+		return fallback
+	}
+	return strings.TrimSpace(text)
+}
+
+// AlertDetails returns the run details attached to every alert about a
+// run. Aborted-run alerts used to call the total "processed"; both are sent.
+func AlertDetails(result *RunResult) map[string]string {
+	return map[string]string{
+		"run_id":           result.RunID,
+		"policy_hash":      result.PolicyHash,
+		"found":            fmt.Sprintf("%d", result.Found),
+		"processed":        fmt.Sprintf("%d", result.Total),
+		"total":            fmt.Sprintf("%d", result.Total),
+		"failed":           fmt.Sprintf("%d", result.Failed),
+		"chronic_failures": strings.Join(result.EscalationKeys(), ", "),
+		"regressions":      strings.Join(result.WarningMessages(worker.WarningRegression), "; "),
+	}
+}
+
+// FailureRateExceeded reports whether a run should alert: every issue
+// failed, or the failure percentage exceeds a non-zero threshold
+func FailureRateExceeded(total, failed int, thresholdPercent float64) bool {
+	if total == 0 || failed == 0 {
+		return false
+	}
+	if failed == total {
+		return true
+	}
+	return thresholdPercent > 0 && float64(failed)*100/float64(total) > thresholdPercent
+}
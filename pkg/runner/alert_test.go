@@ -0,0 +1,63 @@
+package runner_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/notify"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/runner"
+)
+
+// recordingNotifier keeps every alert it is sent
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []notify.Alert
+}
+
+func (n *recordingNotifier) Send(alert notify.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestRunAlertsRegisteredNotifiers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorMessages":["search failed"]}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := &recordingNotifier{}
+	notify.Register("test-recorder", notifier)
+
+	cfg := &runner.Config{
+		JiraBaseURL:    server.URL,
+		JiraEmail:      "user@example.com",
+		JiraAPIToken:   "token",
+		JiraAuthMethod: "basic",
+		JiraProjectKey: "PROJ",
+		ArchiveLabel:   "archive",
+		SearchPageSize: 100,
+		DryRun:         true,
+	}
+	result, err := runner.Run(context.Background(), cfg, runner.Options{})
+	if err == nil || result != nil {
+		t.Fatalf("Run() = %v, %v; want a search failure", result, err)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("registered notifier received %d alerts, want 1", len(notifier.alerts))
+	}
+	alert := notifier.alerts[0]
+	if alert.Summary != "Bulk archive run failed" {
+		t.Errorf("alert summary = %q, want %q", alert.Summary, "Bulk archive run failed")
+	}
+	if alert.Details["error"] == "" || alert.Details["project_key"] != "PROJ" {
+		t.Errorf("alert details = %v, want the error and project key", alert.Details)
+	}
+}
@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/checkpoint"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// checkpointer keeps CHECKPOINT_FILE up to date during a run. A nil
+// checkpointer does nothing.
+type checkpointer struct {
+	path   string
+	logger logging.Logger
+
+	mu      sync.Mutex
+	cp      *checkpoint.Checkpoint
+	resumed bool
+	runID   string
+}
+
+// openCheckpoint starts a checkpoint at path, or continues the one there
+// when resume is set. It returns nil without a path.
+func openCheckpoint(path string, resume bool, logger logging.Logger) (*checkpointer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	c := &checkpointer{path: path, logger: logger}
+	if resume {
+		cp, err := checkpoint.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		if cp == nil {
+			logger.Infof("No checkpoint at %s; starting from the beginning", path)
+		} else {
+			logger.Infof("Resuming from %s: %d issues processed in %d batches, last updated %s",
+				path, len(cp.Outcomes), len(cp.Batches), cp.UpdatedAt.Format(time.RFC3339))
+			c.cp, c.resumed = cp, true
+		}
+	}
+	if c.cp == nil {
+		c.cp = checkpoint.New(time.Now())
+	}
+	return c, nil
+}
+
+// watchSearch records the search's page tokens in the checkpoint
+func (c *checkpointer) watchSearch(client *jira.Client) {
+	if c == nil {
+		return
+	}
+	client.SetSearchPageHook(func(jql, nextPageToken string, fetched int) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cp.Search = checkpoint.Search{JQL: jql, NextPageToken: nextPageToken, Issues: fetched}
+	})
+}
+
+// searched records the completed search and leaves out the issues the
+// checkpoint already has as archived. Jira's search index can lag behind
+// archiving, so a resumed search may still return some of them.
+func (c *checkpointer) searched(selector, policyHash string, issues []jira.Issue) []jira.Issue {
+	if c == nil {
+		return issues
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed && c.cp.PolicyHash != "" && c.cp.PolicyHash != policyHash {
+		c.logger.Warnf("Warning: policy changed since the checkpoint was written (%s -> %s)", c.cp.PolicyHash, policyHash)
+	}
+	c.cp.Selector = selector
+	c.cp.PolicyHash = policyHash
+	c.cp.Search.Completed = true
+
+	var left []jira.Issue
+	for _, issue := range issues {
+		if !c.cp.Archived(issue.Key) {
+			left = append(left, issue)
+		}
+	}
+	if skipped := len(issues) - len(left); skipped > 0 {
+		c.logger.Infof("Skipping %d issues the checkpoint records as archived", skipped)
+	}
+	return left
+}
+
+// started records the ID of the run about to archive
+func (c *checkpointer) started(runID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runID = runID
+	if c.cp.RunID == "" {
+		c.cp.RunID = runID
+	}
+}
+
+// batchDone records a finished batch and saves the checkpoint
+func (c *checkpointer) batchDone(batch int, results []worker.ArchiveResult) {
+	if c == nil {
+		return
+	}
+	outcomes := make(map[string]string, len(results))
+	for _, r := range results {
+		switch {
+		case r.Success:
+			outcomes[r.IssueKey] = history.StatusArchived
+		case r.Skipped:
+			outcomes[r.IssueKey] = history.StatusSkipped
+		default:
+			outcomes[r.IssueKey] = history.StatusFailed
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cp.AddBatch(c.runID, batch, outcomes, time.Now())
+	c.save()
+}
+
+// finish removes the checkpoint after a complete run and keeps it, saved,
+// when the run stopped early so that --resume can continue it
+func (c *checkpointer) finish(err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		if rmErr := checkpoint.Remove(c.path); rmErr != nil {
+			c.logger.Warnf("Failed to remove checkpoint %s: %v", c.path, rmErr)
+		}
+		return
+	}
+	if c.save() {
+		c.logger.Infof("Progress saved to %s; run again with --resume to continue", c.path)
+	}
+}
+
+// save writes the checkpoint, logging failures; the caller holds c.mu
+func (c *checkpointer) save() bool {
+	if err := c.cp.Save(c.path, time.Now()); err != nil {
+		c.logger.Warnf("Failed to save checkpoint %s: %v", c.path, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// recordHistory appends the run to the history file, if configured, and
+// returns the issues that have now failed in ESCALATION_RUNS consecutive runs
+func recordHistory(cfg *config.Config, result *worker.RunResult, warnings *worker.Warnings, logger logging.Logger) map[string]int {
+	if cfg.HistoryFile == "" {
+		return nil
+	}
+
+	run := history.Run{
+		ID:         result.RunID,
+		StartedAt:  result.StartedAt.UTC(),
+		FinishedAt: result.FinishedAt.UTC(),
+		Selector:   result.Selector,
+		ProjectKey: result.ProjectKey,
+		Label:      result.Label,
+		PolicyHash: result.PolicyHash,
+		Total:      result.Total,
+		Aborted:    result.Stopped(),
+	}
+	for _, r := range result.Results {
+		outcome := history.IssueOutcome{Key: r.IssueKey}
+		switch {
+		case r.Success:
+			outcome.Status = history.StatusArchived
+			run.Succeeded++
+		case r.Skipped:
+			outcome.Status = history.StatusSkipped
+			run.Skipped++
+		default:
+			outcome.Status = history.StatusFailed
+			outcome.Permanent = r.Permanent
+			run.Failed++
+		}
+		if r.Error != nil {
+			outcome.Error = r.Error.Error()
+		}
+		run.Issues = append(run.Issues, outcome)
+	}
+
+	store := history.Open(cfg.HistoryFile)
+	if err := store.Append(run); err != nil {
+		logger.Warnf("Failed to record run history: %v", err)
+		return nil
+	}
+	logger.Infof("Recorded run %s in %s", run.ID, cfg.HistoryFile)
+
+	runs, err := store.Runs()
+	if err != nil {
+		logger.Warnf("Failed to read run history: %v", err)
+		return nil
+	}
+	if len(runs) >= 2 {
+		if previous := runs[len(runs)-2]; previous.PolicyHash != "" && previous.PolicyHash != run.PolicyHash {
+			logger.Warnf("Warning: policy changed since run %s (%s -> %s)", previous.ID, previous.PolicyHash, run.PolicyHash)
+			warnings.Add(worker.WarningPolicyChanged, nil, "Policy changed since run %s (%s -> %s)", previous.ID, previous.PolicyHash, run.PolicyHash)
+		}
+	}
+	for _, regression := range history.Regressions(runs, cfg.RegressionRuns, cfg.RegressionFactor) {
+		logger.Warnf("Warning: %s", regression)
+		warnings.Add(worker.WarningRegression, nil, "%s", regression)
+	}
+	return history.ChronicFailures(runs, cfg.EscalationRuns)
+}
+
+// skipIneligible leaves out the issues that failed permanently, such as
+// unsupported issue types or archived projects, within the last
+// SKIP_INELIGIBLE_DAYS days according to the history file
+func skipIneligible(cfg *config.Config, issues []jira.Issue, logger logging.Logger) []jira.Issue {
+	if cfg.HistoryFile == "" || cfg.SkipIneligibleDays == 0 || len(issues) == 0 {
+		return issues
+	}
+
+	runs, err := history.Open(cfg.HistoryFile).Runs()
+	if err != nil {
+		logger.Warnf("Not skipping ineligible issues: %v", err)
+		return issues
+	}
+	since := time.Now().AddDate(0, 0, -cfg.SkipIneligibleDays)
+	ineligible := history.Ineligible(runs, since)
+
+	kept := issues[:0:0]
+	for _, issue := range issues {
+		if _, skip := ineligible[issue.Key]; !skip {
+			kept = append(kept, issue)
+		}
+	}
+	if skipped := len(issues) - len(kept); skipped > 0 {
+		logger.Infof("Leaving out %d issues that failed permanently in the last %d days", skipped, cfg.SkipIneligibleDays)
+	}
+	return kept
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/suggest"
+)
+
+// checkLabel explains an empty label search. A label that is not used
+// anywhere on the site is almost always a typo in ARCHIVE_LABEL, so it is
+// reported together with the closest existing labels. The check is best
+// effort and only costs API calls when the search found nothing.
+func checkLabel(client *jira.Client, label string) {
+	logger := client.Logger()
+	labels, err := client.GetLabels()
+	if err != nil {
+		logger.Warnf("Could not verify that label '%s' exists: %v", label, err)
+		return
+	}
+
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return
+		}
+	}
+
+	if matches := suggest.Closest(label, labels, 3); len(matches) > 0 {
+		logger.Warnf("Label '%s' not found; did you mean '%s'?", label, strings.Join(matches, "', '"))
+		return
+	}
+	logger.Warnf("Label '%s' not found on this site. Check ARCHIVE_LABEL.", label)
+}
+
+// checkProjects explains a failed search when JIRA_PROJECT_KEY names a
+// project the user cannot see. It returns an error naming the closest
+// project keys, or nil when every project exists or the projects cannot be
+// listed, in which case the original search error stands.
+func checkProjects(client *jira.Client, projectKeys []string) error {
+	logger := client.Logger()
+	projects, err := client.GetProjects()
+	if err != nil {
+		logger.Warnf("Could not verify that project '%s' exists: %v", strings.Join(projectKeys, "', '"), err)
+		return nil
+	}
+
+	keys := make([]string, 0, len(projects))
+	visible := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		keys = append(keys, p.Key)
+		visible[strings.ToUpper(p.Key)] = true
+	}
+
+	for _, projectKey := range projectKeys {
+		if visible[strings.ToUpper(projectKey)] {
+			continue
+		}
+		if matches := suggest.Closest(projectKey, keys, 3); len(matches) > 0 {
+			return fmt.Errorf("project %s not found; did you mean %s?", projectKey, strings.Join(matches, ", "))
+		}
+		return fmt.Errorf("project %s not found or not visible to JIRA_EMAIL", projectKey)
+	}
+	return nil
+}
+
+// checkExclusions rejects EXCLUDE_STATUSES and EXCLUDE_ISSUE_TYPES entries
+// naming no status or issue type on the site. A misspelt name would silently
+// exclude nothing and let the issues it was meant to protect be archived.
+// Entries are not checked when the catalog cannot be listed.
+func checkExclusions(client *jira.Client, statuses, issueTypes []string) error {
+	logger := client.Logger()
+	if len(statuses) > 0 {
+		details, err := client.Statuses()
+		if err != nil {
+			logger.Warnf("Could not verify EXCLUDE_STATUSES: %v", err)
+		} else {
+			names := make([]string, 0, len(details))
+			for _, s := range details {
+				names = append(names, s.Name)
+			}
+			if err := checkNames("EXCLUDE_STATUSES", "status", statuses, names); err != nil {
+				return err
+			}
+		}
+	}
+	if len(issueTypes) > 0 {
+		types, err := client.IssueTypes()
+		if err != nil {
+			logger.Warnf("Could not verify EXCLUDE_ISSUE_TYPES: %v", err)
+		} else {
+			names := make([]string, 0, len(types))
+			for _, t := range types {
+				names = append(names, t.Name)
+			}
+			if err := checkNames("EXCLUDE_ISSUE_TYPES", "issue type", issueTypes, names); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkNames returns an error for the first of values, set by variable,
+// that matches none of the known names, ignoring case
+func checkNames(variable, kind string, values, known []string) error {
+	for _, v := range values {
+		if slices.ContainsFunc(known, func(name string) bool { return strings.EqualFold(name, v) }) {
+			continue
+		}
+		if matches := suggest.Closest(v, known, 3); len(matches) > 0 {
+			return fmt.Errorf("%s: %s '%s' not found; did you mean '%s'?", variable, kind, v, strings.Join(matches, "', '"))
+		}
+		return fmt.Errorf("%s: %s '%s' not found on this site", variable, kind, v)
+	}
+	return nil
+}
+
+// resolveSearchFields replaces SEARCH_FIELDS entries naming a field, such
+// as "Team", with the field's ID, which is what searches expect and what
+// IssueFields.Extra is keyed by. Entries are kept as given when the fields
+// cannot be listed.
+func resolveSearchFields(client *jira.Client, fields []string) []string {
+	logger := client.Logger()
+	if _, err := client.Fields(); err != nil {
+		logger.Warnf("Could not look up SEARCH_FIELDS: %v", err)
+		return fields
+	}
+
+	resolved := make([]string, len(fields))
+	for i, f := range fields {
+		id, err := client.FieldID(f)
+		if err != nil {
+			logger.Warnf("SEARCH_FIELDS entry '%s' is left as given: %v", f, err)
+			id = f
+		} else if id != f {
+			logger.Infof("SEARCH_FIELDS entry '%s' is field %s", f, id)
+		}
+		resolved[i] = id
+	}
+	return resolved
+}
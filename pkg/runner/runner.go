@@ -0,0 +1,393 @@
+// Package runner executes a complete archive run (search, filter, archive,
+// history and report) so other Go programs can embed the tool instead of
+// running the binary.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/export"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/freeze"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/history"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/selector"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/warehouse"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// Config is the run configuration, normally loaded from the environment.
+// It is an alias so callers outside this module can build one.
+type Config = config.Config
+
+// LoadConfig loads the configuration from environment variables
+func LoadConfig() (*Config, error) {
+	return config.Load()
+}
+
+// Issue is a Jira issue as returned by the search
+type Issue = jira.Issue
+
+// Options adjusts a run beyond what the configuration covers
+type Options struct {
+	// Progress receives machine-readable progress events (nil disables)
+	Progress *worker.Progress
+
+	// Logger receives the run's logs, with a run_id attribute once the run
+	// has an ID. Nil uses the standard log package, as the command does.
+	Logger *slog.Logger
+
+	// Filter narrows the selected issues before they are archived. The run
+	// ends without archiving if it returns no issues.
+	Filter func(issues []Issue) ([]Issue, error)
+
+	// Warehouse receives the per-issue outcomes after archiving, instead of
+	// the sink configured by WAREHOUSE_SINK
+	Warehouse warehouse.Sink
+
+	// Resume continues from CHECKPOINT_FILE, skipping the issues it
+	// records as archived, instead of starting a new checkpoint
+	Resume bool
+}
+
+// RunResult is the outcome of Run
+type RunResult = worker.RunResult
+
+// NewClient creates a Jira client with the configured API call budget,
+// retries, slow request log and search options. It also applies
+// MAX_REQUESTS_PER_SECOND, which limits every client sharing the HTTP
+// transport, the Jira client included.
+func NewClient(cfg *Config) *jira.Client {
+	transport.SetRateLimit(cfg.MaxRequestsPerSecond)
+	client := jira.NewClient(cfg.JiraBaseURL, cfg.JiraEmail, cfg.JiraAPIToken)
+	client.SetAuthMethod(cfg.JiraAuthMethod)
+	client.SetMaxAPICalls(cfg.MaxAPICalls)
+	client.SetMaxRetries(cfg.MaxRetries)
+	client.SetRetryBackoff(time.Duration(cfg.RetryBaseDelayMS)*time.Millisecond, cfg.RetryJitter)
+	client.SetSlowRequestThreshold(time.Duration(cfg.SlowRequestMS) * time.Millisecond)
+	client.SetSearchHedge(time.Duration(cfg.SearchHedgeMS) * time.Millisecond)
+	client.SetMetadataCache(cfg.MetadataCacheDir)
+	client.SetMetadataTTL(time.Duration(cfg.MetadataTTLSeconds) * time.Second)
+	client.SetSearchPageSize(cfg.SearchPageSize)
+	client.SetSearchFields(cfg.SearchFields)
+	client.SetSearchExpand(cfg.SearchExpand)
+	return client
+}
+
+// Run searches for the configured issues, archives them, records the run
+// in the history file and returns the result. With DRY_RUN it stops after
+// batching and returns the planned batches in RunResult.Plan instead.
+//
+// Errors that prevent archiving, such as a failed search, are returned
+// without a result; errors.Is(err, jira.ErrAPIBudgetExhausted) tells a
+// budget exhausted by the search apart. When archiving stops early
+// (worker.ErrFailureRateExceeded, worker.ErrCanaryFailed,
+// worker.ErrStoppedOnBudget or ctx being done), the partial result is
+// returned together with the error and RunResult.Remaining lists the issues
+// left. Cancelling ctx abandons a search in progress but lets a batch
+// already sent finish. With CHECKPOINT_FILE, a run that stops early keeps
+// its progress there for Options.Resume.
+//
+// A failed or aborted run, or failures above ALERT_FAILURE_RATE, are
+// alerted to the configured notifiers and those registered with
+// notify.Register.
+func Run(ctx context.Context, cfg *Config, opts Options) (*RunResult, error) {
+	result, err := run(ctx, cfg, opts)
+	alertRun(cfg, logging.NewLogger(opts.Logger), result, err)
+	return result, err
+}
+
+func run(ctx context.Context, cfg *Config, opts Options) (*RunResult, error) {
+	logger := logging.NewLogger(opts.Logger)
+
+	// Skip the run entirely during release freezes and audits
+	calendar, err := freeze.Load(cfg.FreezeDates, cfg.FreezeCalendarURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load freeze calendar: %w", err)
+	}
+	if window, frozen := calendar.Active(time.Now()); frozen {
+		logger.Infof("Freeze window active: %s. Skipping run.", window)
+		return &RunResult{Frozen: window.String()}, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	client := NewClient(cfg)
+	client.SetLogger(opts.Logger)
+
+	if opts.Resume && cfg.CheckpointFile == "" {
+		return nil, fmt.Errorf("resuming requires CHECKPOINT_FILE")
+	}
+	var checkpoints *checkpointer
+	if !cfg.DryRun {
+		checkpoints, err = openCheckpoint(cfg.CheckpointFile, opts.Resume, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+		checkpoints.watchSearch(client)
+	}
+
+	searchStart := time.Now()
+	source, issues, err := SelectContext(ctx, cfg, client)
+	searchTime := time.Since(searchStart)
+	if err != nil {
+		checkpoints.finish(err)
+	}
+	if errors.Is(err, jira.ErrAPIBudgetExhausted) {
+		logger.Warnf("Stopping run: API call budget of %d exhausted while searching for issues. No issues were archived.", cfg.MaxAPICalls)
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for issues: %w", err)
+	}
+	issues = skipIneligible(cfg, issues, logger)
+	policyHash := cfg.PolicyHash(source.Name())
+	issues = checkpoints.searched(source.Name(), policyHash, issues)
+
+	logger.Infof("Found %d issues to archive from %s", len(issues), source.Name())
+	logger.Infof("Policy hash: %s", policyHash)
+	opts.Progress.Emit(worker.ProgressEvent{Event: worker.EventSearchCompleted, Total: len(issues)})
+
+	result := &RunResult{
+		ProjectKey: cfg.JiraProjectKey,
+		Label:      cfg.ArchiveLabel,
+		Selector:   source.Name(),
+		PolicyHash: policyHash,
+		Found:      len(issues),
+		Timings:    worker.Timings{Search: searchTime},
+	}
+
+	if len(issues) == 0 {
+		logger.Infof("No issues to archive. Exiting.")
+		checkpoints.finish(nil)
+		return result, nil
+	}
+
+	if opts.Filter != nil {
+		issues, err = opts.Filter(issues)
+		if err != nil {
+			return nil, err
+		}
+		if len(issues) == 0 {
+			return result, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Create archiver and process issues concurrently
+	warnings := &worker.Warnings{}
+	archiver := worker.NewArchiver(client, cfg.MaxWorkers)
+	archiver.SetProgress(opts.Progress)
+	archiver.SetWarnings(warnings)
+	archiver.SetAbortThreshold(cfg.AbortFailureRate, cfg.AbortMinBatches)
+	archiver.SetCanary(cfg.CanarySize)
+	archiver.SetVerify(cfg.VerifyArchived, cfg.VerifyConcurrency)
+	archiver.SetPreflight(cfg.EligibilityPreflight)
+	archiver.SetSecurityLevels(cfg.ArchiveSkipSecured, cfg.ArchiveSecurityLevels)
+	archiver.SetServiceDeskPolicy(cfg.ServiceDeskPolicy)
+	archiver.SetExclusions(worker.Exclusions{
+		Labels:     cfg.ExcludeLabels,
+		Statuses:   cfg.ExcludeStatuses,
+		IssueTypes: cfg.ExcludeIssueTypes,
+	})
+	archiver.SetPartitionByProject(cfg.PartitionByProject)
+	archiver.SetArchiveByID(cfg.ArchiveByID)
+	if cfg.RemoveTriggerLabel {
+		archiver.SetFailureLabels(cfg.FailureLabel, cfg.ArchiveLabel)
+	} else {
+		archiver.SetFailureLabels(cfg.FailureLabel, "")
+	}
+	if cfg.ArchiveComment != "" {
+		tmpl, err := template.New("comment").Parse(cfg.ArchiveComment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ARCHIVE_COMMENT template: %w", err)
+		}
+		archiver.SetComment(tmpl, worker.CommentData{
+			Label:      cfg.ArchiveLabel,
+			Selector:   source.Name(),
+			PolicyHash: policyHash,
+		}, cfg.ArchiveCommentRate)
+	}
+	if cfg.DryRun {
+		result.DryRun = true
+		result.Plan = archiver.Plan(issues)
+		result.Requests = client.Stats()
+		logger.Infof("Dry run: %d issues in %d batches would be archived; nothing was changed", len(issues), len(result.Plan))
+		return result, nil
+	}
+
+	runStart := time.Now()
+	runID := history.NewRunID(runStart)
+	logger.Infof("Run ID: %s", runID)
+	logger = logger.With("run_id", runID)
+	client.SetLogger(logger.Slog())
+	archiver.SetLogger(logger.Slog())
+	archiver.SetRunProperty(cfg.RunPropertyKey, worker.RunProperty{RunID: runID, PolicyHash: policyHash})
+	if checkpoints != nil {
+		checkpoints.started(runID)
+		archiver.SetBatchHook(checkpoints.batchDone)
+	}
+	var exporter export.Writer
+	if cfg.ExportDir != "" {
+		exporter, err = export.Open(cfg.ExportDir, cfg.ExportFormat, runID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start export: %w", err)
+		}
+		archiver.SetExport(exporter, cfg.ExportWorkers)
+	}
+	results, archiveErr := archiver.ArchiveIssuesContext(ctx, issues)
+	checkpoints.finish(archiveErr)
+	if exporter != nil {
+		if err := exporter.Close(); err != nil {
+			logger.Warnf("Failed to finish export %s: %v", exporter.Path(), err)
+		} else {
+			logger.Infof("Exported issues to %s", exporter.Path())
+		}
+	}
+
+	result.Summary = worker.Summarize(results)
+	result.Projects = worker.SummarizeProjects(results)
+	result.ServiceDesk = worker.SummarizeServiceDesk(issues, results)
+	result.RunID = runID
+	result.StartedAt = runStart
+	result.FinishedAt = time.Now()
+	result.Timings.Archive = result.FinishedAt.Sub(runStart)
+	result.Requests = client.Stats()
+	if archiveErr != nil {
+		result.Error = archiveErr.Error()
+		result.Remaining = remaining(issues, results)
+	}
+
+	if result.Skipped > 0 {
+		var skipped []string
+		for _, r := range result.Results {
+			if r.Skipped {
+				skipped = append(skipped, r.IssueKey)
+			}
+		}
+		warnings.Add(worker.WarningIssuesSkipped, skipped, "%d issues were skipped by the eligibility checks", result.Skipped)
+	}
+	if unresolved := worker.UnresolvedServiceDeskRequests(issues, results); len(unresolved) > 0 {
+		warnings.Add(worker.WarningUnresolvedRequests, unresolved, "%d unresolved service desk requests were archived", len(unresolved))
+	}
+	result.Escalations = worker.Escalations(recordHistory(cfg, result, warnings, logger))
+	result.Warnings = warnings.List()
+
+	if cfg.AuditCrossCheck {
+		result.Audit = crossCheckAudit(client, results, runStart)
+	}
+	loadWarehouse(cfg, opts.Warehouse, result, logger)
+	if errors.Is(archiveErr, worker.ErrStoppedOnBudget) {
+		logger.Warnf("Run stopped after %d API calls: %v", client.APICalls(), archiveErr)
+	}
+	return result, archiveErr
+}
+
+// Select resolves the configured selection: the archive label by default,
+// the JIRA_JQL query or the SELECTOR expression. Issues are returned in key
+// order.
+func Select(cfg *Config, client *jira.Client) (selector.Source, []jira.Issue, error) {
+	return SelectContext(context.Background(), cfg, client)
+}
+
+// SelectContext is Select, abandoning the search once ctx is done
+func SelectContext(ctx context.Context, cfg *Config, client *jira.Client) (selector.Source, []jira.Issue, error) {
+	logger := client.Logger()
+	if len(cfg.SearchFields) > 0 {
+		client.SetSearchFields(resolveSearchFields(client, cfg.SearchFields))
+	}
+	if err := checkExclusions(client, cfg.ExcludeStatuses, cfg.ExcludeIssueTypes); err != nil {
+		return nil, nil, err
+	}
+	var source selector.Source
+	switch {
+	case cfg.JQL != "":
+		jql := cfg.SearchJQL("")
+		logger.Infof("Searching for issues matching JQL: %s", jql)
+		source = &selector.JQL{Client: client, Query: jql}
+	case cfg.Selector == "":
+		projects := cfg.ProjectKeys()
+		if len(projects) == 1 {
+			logger.Infof("Searching for issues with label '%s' in project '%s'...", cfg.ArchiveLabel, projects[0])
+		} else {
+			logger.Infof("Searching for issues with label '%s' in %d projects (%s)...", cfg.ArchiveLabel, len(projects), strings.Join(projects, ", "))
+		}
+		if narrow := cfg.NarrowJQL(); narrow != "" {
+			logger.Infof("Only issues matching: %s", narrow)
+		}
+		source = selector.LabelsWhere(client, projects, cfg.ArchiveLabel, cfg.NarrowJQL())
+	default:
+		var err error
+		source, err = selector.Parse(cfg.Selector, client, cfg.JiraProjectKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid SELECTOR: %w", err)
+		}
+		logger.Infof("Selecting issues from %s...", source.Name())
+	}
+
+	issues, err := selector.IssuesContext(ctx, source)
+	if err != nil && cfg.JiraProjectKey != "" && !errors.Is(err, jira.ErrAPIBudgetExhausted) && ctx.Err() == nil {
+		if projectErr := checkProjects(client, cfg.ProjectKeys()); projectErr != nil {
+			return nil, nil, projectErr
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(issues) == 0 && cfg.Selector == "" && cfg.JQL == "" {
+		checkLabel(client, cfg.ArchiveLabel)
+	}
+	// Process and report in key order so consecutive runs are comparable
+	jira.SortIssues(issues)
+	return source, issues, nil
+}
+
+// remaining returns the keys of the issues without a result, in key order
+func remaining(issues []jira.Issue, results []worker.ArchiveResult) []string {
+	done := make(map[string]bool, len(results))
+	for _, r := range results {
+		done[r.IssueKey] = true
+	}
+	var keys []string
+	for _, issue := range issues {
+		if !done[issue.Key] {
+			keys = append(keys, issue.Key)
+		}
+	}
+	return keys
+}
+
+// crossCheckAudit reconciles the run's results against Jira's audit log.
+// Mismatches are reported but do not fail the run.
+func crossCheckAudit(client *jira.Client, results []worker.ArchiveResult, runStart time.Time) *worker.AuditCrossCheck {
+	logger := client.Logger()
+	// Allow for clock skew between this host and Jira
+	from := runStart.Add(-5 * time.Minute)
+	to := time.Now().Add(5 * time.Minute)
+
+	records, err := client.GetAuditRecords("archived", from, to)
+	if err != nil {
+		logger.Warnf("Audit cross-check skipped: %v", err)
+		return nil
+	}
+
+	check := worker.CrossCheckAudit(results, records)
+	if check.HasMismatches() {
+		logger.Warnf("Audit cross-check found %d missing and %d unexpected archive records",
+			len(check.MissingAudit), len(check.Unexpected))
+	}
+	return check
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/config"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/warehouse"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// loadWarehouse loads the run's per-issue outcomes into sink, or into the
+// sink configured by WAREHOUSE_SINK when sink is nil. Failures are logged
+// and do not fail the run.
+func loadWarehouse(cfg *config.Config, sink warehouse.Sink, result *worker.RunResult, logger logging.Logger) {
+	if sink == nil {
+		if cfg.WarehouseSink == "" {
+			return
+		}
+		var err error
+		sink, err = warehouse.New(cfg.WarehouseSink, warehouse.Options{
+			Table:  cfg.WarehouseTable,
+			URL:    cfg.WarehouseURL,
+			Method: cfg.WarehouseMethod,
+			Format: cfg.WarehouseFormat,
+			Token:  cfg.WarehouseToken,
+		})
+		if err != nil {
+			logger.Warnf("Failed to set up warehouse sink: %v", err)
+			return
+		}
+	}
+
+	rows := warehouse.Rows(result)
+	if err := sink.Load(rows); err != nil {
+		logger.Warnf("Failed to load %d issue outcomes into the warehouse: %v", len(rows), err)
+		return
+	}
+	logger.Infof("Loaded %d issue outcomes into the warehouse", len(rows))
+}
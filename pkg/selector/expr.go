@@ -0,0 +1,247 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Parse builds a Source from a selector expression combining "kind:value"
+// selectors with set operations:
+//
+//	A | B   or  A ∪ B    union
+//	A & B   or  A ∩ B    intersection (binds tighter than union/difference)
+//	A - B   or  A − B    difference
+//	( ... )              grouping
+//
+// Values containing spaces or operator characters must be double-quoted,
+// e.g. jql:"status = Done". A bare path ending in .jql, such as
+// protected.jql, is short for jqlfile:protected.jql. A lone jql: selector
+// may be given unquoted, e.g. jql:project = X AND status in (Done, Closed).
+// projectKey may be a comma-separated list; label selectors then search
+// each project.
+func Parse(expr string, client *jira.Client, projectKey string) (Source, error) {
+	if isLoneQuery(expr) {
+		return parseAtom(expr, client, projectKey)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector expression %q: %w", expr, err)
+	}
+	p := &parser{tokens: tokens, client: client, projectKey: projectKey}
+	source, err := p.parseExpr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector expression %q: %w", expr, err)
+	}
+	return source, nil
+}
+
+// isLoneQuery reports whether expr is a single jql: selector with an
+// unquoted value, which is taken as is: JQL often contains spaces,
+// parentheses or '-'
+func isLoneQuery(expr string) bool {
+	kind, value, ok := strings.Cut(strings.TrimSpace(expr), ":")
+	return ok && strings.EqualFold(kind, "jql") && !strings.HasPrefix(strings.TrimSpace(value), `"`)
+}
+
+type tokenKind int
+
+const (
+	tokenAtom tokenKind = iota
+	tokenUnion
+	tokenIntersect
+	tokenDifference
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var operators = map[rune]tokenKind{
+	'|': tokenUnion,
+	'∪': tokenUnion,
+	'&': tokenIntersect,
+	'∩': tokenIntersect,
+	'-': tokenDifference,
+	'−': tokenDifference,
+	'(': tokenOpen,
+	')': tokenClose,
+}
+
+// isOperator reports whether r ends a bare word; '-' is common inside label
+// names and paths, so only a spaced '-' is an operator
+func isOperator(r rune) bool {
+	kind, ok := operators[r]
+	return ok && kind != tokenDifference
+}
+
+// tokenize splits an expression into operators and "kind:value" atoms
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+		if kind, ok := operators[r]; ok {
+			tokens = append(tokens, token{kind: kind, text: string(r)})
+			i++
+			continue
+		}
+
+		// Atom: kind up to ':', then a quoted or bare value
+		start := i
+		for i < len(runes) && runes[i] != ':' && !unicode.IsSpace(runes[i]) && !isOperator(runes[i]) {
+			i++
+		}
+		if i >= len(runes) || runes[i] != ':' {
+			word := string(runes[start:i])
+			if strings.HasSuffix(strings.ToLower(word), ".jql") {
+				tokens = append(tokens, token{kind: tokenAtom, text: "jqlfile:" + word})
+				continue
+			}
+			return nil, fmt.Errorf("expected kind:value at %q", word)
+		}
+		kind := string(runes[start:i])
+		i++
+
+		var value strings.Builder
+		if i < len(runes) && runes[i] == '"' {
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated quote in %s selector", kind)
+			}
+			i++
+		} else {
+			for ; i < len(runes); i++ {
+				if unicode.IsSpace(runes[i]) {
+					break
+				}
+				if isOperator(runes[i]) {
+					break
+				}
+				value.WriteRune(runes[i])
+			}
+		}
+
+		tokens = append(tokens, token{kind: tokenAtom, text: kind + ":" + value.String()})
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	client     *jira.Client
+	projectKey string
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseExpr parses unions and differences, left to right
+func (p *parser) parseExpr() (Source, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || (tok.kind != tokenUnion && tok.kind != tokenDifference) {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.kind == tokenUnion {
+			if union, ok := left.(Union); ok {
+				left = append(union, right)
+			} else {
+				left = Union{left, right}
+			}
+		} else {
+			left = &Difference{From: left, Minus: right}
+		}
+	}
+}
+
+// parseTerm parses intersections
+func (p *parser) parseTerm() (Source, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenIntersect {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		if intersection, ok := left.(Intersection); ok {
+			left = append(intersection, right)
+		} else {
+			left = Intersection{left, right}
+		}
+	}
+}
+
+// parseFactor parses an atom or a parenthesized expression
+func (p *parser) parseFactor() (Source, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	switch tok.kind {
+	case tokenAtom:
+		return parseAtom(tok.text, p.client, p.projectKey)
+	case tokenOpen:
+		source, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != tokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return source, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+}
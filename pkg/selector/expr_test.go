@@ -0,0 +1,97 @@
+package selector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// label returns the name of the label selector for name in project PROJ
+func label(name string) string {
+	return "jql:" + jira.LabelJQL("PROJ", name)
+}
+
+func TestParse(t *testing.T) {
+	dir := t.TempDir()
+	protected := filepath.Join(dir, "protected.jql")
+	if err := os.WriteFile(protected, []byte("labels = legal-hold\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"label:stale", label("stale")},
+		{"filter:10432", "filter:10432"},
+		{"label:a | label:b", "(" + label("a") + " ∪ " + label("b") + ")"},
+		// Intersection binds tighter than union and difference
+		{"label:a | label:b & label:c", "(" + label("a") + " ∪ (" + label("b") + " ∩ " + label("c") + "))"},
+		{"label:a & label:b - label:c", "((" + label("a") + " ∩ " + label("b") + ") − " + label("c") + ")"},
+		// Union and difference are left-associative
+		{"label:a - label:b | label:c", "((" + label("a") + " − " + label("b") + ") ∪ " + label("c") + ")"},
+		{"label:a - (label:b | label:c)", "(" + label("a") + " − (" + label("b") + " ∪ " + label("c") + "))"},
+		{"(label:a ∪ filter:1) ∩ board:2", "((" + label("a") + " ∪ filter:1) ∩ board:2)"},
+		// An unspaced '-' belongs to the value
+		{"label:legal-hold", label("legal-hold")},
+		{"label:stale - label:legal-hold", "(" + label("stale") + " − " + label("legal-hold") + ")"},
+		// Quoted values may contain spaces and operators
+		{`jql:"status = Done" - label:x`, "(jql:status = Done − " + label("x") + ")"},
+		{`jql:"summary ~ \"a | b\"" | label:x`, `(jql:summary ~ "a | b" ∪ ` + label("x") + ")"},
+		// A lone jql: selector needs no quotes
+		{"jql:project = X AND status in (Done, Closed)", "jql:project = X AND status in (Done, Closed)"},
+		// A bare .jql path is a JQL file
+		{"(label:stale ∪ filter:10432) − " + protected, "((" + label("stale") + " ∪ filter:10432) − jql:labels = legal-hold)"},
+		{"jqlfile:" + protected, "jql:labels = legal-hold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			source, err := Parse(tt.expr, nil, "PROJ")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := source.Name(); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMultipleProjects(t *testing.T) {
+	source, err := Parse("label:stale", nil, "PROJ,OPS")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := "(jql:" + jira.LabelJQL("PROJ", "stale") + " ∪ jql:" + jira.LabelJQL("OPS", "stale") + ")"
+	if got := source.Name(); got != want {
+		t.Errorf("Parse() = %s, want %s", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"label:stale & (label:done":     "missing closing parenthesis",
+		"label:a | label:b)":            `unexpected ")"`,
+		"label:a |":                     "unexpected end of expression",
+		"& label:a":                     `unexpected "&"`,
+		"label:a label:b":               `unexpected "label:b"`,
+		`jql:"status = Done`:            "unterminated quote",
+		"stale | label:a":               "expected kind:value",
+		"label:a | nope:b":              `unknown selector kind "nope"`,
+		"label:a | jqlfile:missing.jql": "failed to read JQL file",
+	}
+	for expr, want := range tests {
+		t.Run(expr, func(t *testing.T) {
+			source, err := Parse(expr, nil, "PROJ")
+			if err == nil {
+				t.Fatalf("Parse() = %s, want an error", source.Name())
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Parse() error = %v, want it to mention %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package selector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// parseAtom builds a Source from a "kind:value" specification:
+//
+//	label:NAME          issues with the label in the configured projects
+//	jql:QUERY           issues matching a JQL query
+//	jqlfile:PATH        issues matching the JQL query stored in a file
+//	filter:ID           issues matched by a saved filter
+//	board:ID            issues on an Agile board
+//	keys:PATH           issue keys listed in a text file (- for stdin)
+//	csv:PATH[#COLUMN]   issue keys in a CSV column (default column "key")
+func parseAtom(spec string, client *jira.Client, projectKey string) (Source, error) {
+	kind, value, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("invalid selector %q (expected kind:value)", spec)
+	}
+
+	switch strings.ToLower(kind) {
+	case "label":
+		return Labels(client, jira.ParseProjectKeys(projectKey), value), nil
+	case "jql":
+		return &JQL{Client: client, Query: value}, nil
+	case "jqlfile":
+		query, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JQL file: %w", err)
+		}
+		if strings.TrimSpace(string(query)) == "" {
+			return nil, fmt.Errorf("JQL file %s is empty", value)
+		}
+		return &JQL{Client: client, Query: strings.TrimSpace(string(query))}, nil
+	case "filter":
+		return &Filter{Client: client, ID: value}, nil
+	case "board":
+		return &Board{Client: client, ID: value}, nil
+	case "keys":
+		return &KeyFile{Client: client, Path: value}, nil
+	case "csv":
+		path, column, found := strings.Cut(value, "#")
+		if !found {
+			column = "key"
+		}
+		return &CSV{Client: client, Path: path, Column: column}, nil
+	default:
+		return nil, fmt.Errorf("unknown selector kind %q", kind)
+	}
+}
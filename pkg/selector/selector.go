@@ -0,0 +1,141 @@
+package selector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Source selects the issues a run operates on
+type Source interface {
+	// Name describes the source for logs and previews
+	Name() string
+	// Issues resolves the source to a list of issues
+	Issues() ([]jira.Issue, error)
+}
+
+// ContextSource is a Source whose search can be abandoned once a context
+// is done
+type ContextSource interface {
+	Source
+	IssuesContext(ctx context.Context) ([]jira.Issue, error)
+}
+
+// IssuesContext resolves source, passing ctx on if the source accepts it
+func IssuesContext(ctx context.Context, source Source) ([]jira.Issue, error) {
+	if cs, ok := source.(ContextSource); ok {
+		return cs.IssuesContext(ctx)
+	}
+	return source.Issues()
+}
+
+// Union selects issues found by any of its sources
+type Union []Source
+
+// Name describes the union
+func (u Union) Name() string {
+	return join([]Source(u), " ∪ ")
+}
+
+// Issues returns the issues of all sources, deduplicated, in first-seen order
+func (u Union) Issues() ([]jira.Issue, error) {
+	var result []jira.Issue
+	seen := make(map[string]bool)
+	for _, source := range u {
+		issues, err := source.Issues()
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if !seen[issue.Key] {
+				seen[issue.Key] = true
+				result = append(result, issue)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Intersection selects issues found by every one of its sources
+type Intersection []Source
+
+// Name describes the intersection
+func (in Intersection) Name() string {
+	return join([]Source(in), " ∩ ")
+}
+
+// Issues returns the issues of the first source that all other sources also contain
+func (in Intersection) Issues() ([]jira.Issue, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+
+	result, err := in[0].Issues()
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range in[1:] {
+		issues, err := source.Issues()
+		if err != nil {
+			return nil, err
+		}
+		keep := keySet(issues)
+		filtered := result[:0]
+		for _, issue := range result {
+			if keep[issue.Key] {
+				filtered = append(filtered, issue)
+			}
+		}
+		result = filtered
+	}
+	return result, nil
+}
+
+// Difference selects issues of From that are not found by Minus
+type Difference struct {
+	From  Source
+	Minus Source
+}
+
+// Name describes the difference
+func (d *Difference) Name() string {
+	return "(" + d.From.Name() + " − " + d.Minus.Name() + ")"
+}
+
+// Issues returns the issues of From minus those of Minus
+func (d *Difference) Issues() ([]jira.Issue, error) {
+	issues, err := d.From.Issues()
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := d.Minus.Issues()
+	if err != nil {
+		return nil, err
+	}
+
+	drop := keySet(excluded)
+	var result []jira.Issue
+	for _, issue := range issues {
+		if !drop[issue.Key] {
+			result = append(result, issue)
+		}
+	}
+	return result, nil
+}
+
+func keySet(issues []jira.Issue) map[string]bool {
+	set := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		set[issue.Key] = true
+	}
+	return set
+}
+
+func join(sources []Source, sep string) string {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Name()
+	}
+	return "(" + strings.Join(names, sep) + ")"
+}
@@ -0,0 +1,252 @@
+package selector
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// keysPerQuery limits the number of keys in a single "key in (...)" query
+const keysPerQuery = 100
+
+// JQL selects issues matching a JQL query
+type JQL struct {
+	Client *jira.Client
+	Query  string
+}
+
+// Name describes the query
+func (j *JQL) Name() string {
+	return "jql:" + j.Query
+}
+
+// Issues runs the query
+func (j *JQL) Issues() ([]jira.Issue, error) {
+	return j.Client.GetAllIssues(j.Query)
+}
+
+// IssuesContext runs the query, stopping once ctx is done
+func (j *JQL) IssuesContext(ctx context.Context) ([]jira.Issue, error) {
+	return j.Client.GetAllIssuesContext(ctx, j.Query)
+}
+
+// Label selects issues carrying a label in a project
+func Label(client *jira.Client, projectKey, label string) Source {
+	return &JQL{Client: client, Query: jira.LabelJQL(projectKey, label)}
+}
+
+// Labels selects issues carrying a label in any of the projects, searching
+// each project separately. A single project is the same as Label.
+func Labels(client *jira.Client, projectKeys []string, label string) Source {
+	return LabelsWhere(client, projectKeys, label, "")
+}
+
+// LabelsWhere is Labels, narrowed to the issues also matching the JQL
+// clause unless it is empty
+func LabelsWhere(client *jira.Client, projectKeys []string, label, clause string) Source {
+	query := func(key string) Source {
+		return &JQL{Client: client, Query: jira.AndJQL(jira.LabelJQL(key, label), clause)}
+	}
+	if len(projectKeys) == 1 {
+		return query(projectKeys[0])
+	}
+	union := make(Union, len(projectKeys))
+	for i, key := range projectKeys {
+		union[i] = query(key)
+	}
+	return union
+}
+
+// Filter selects issues matched by a saved filter
+type Filter struct {
+	Client *jira.Client
+	ID     string
+}
+
+// Name describes the filter
+func (f *Filter) Name() string {
+	return "filter:" + f.ID
+}
+
+// Issues runs the saved filter
+func (f *Filter) Issues() ([]jira.Issue, error) {
+	return f.Client.GetAllIssues("filter = " + f.ID)
+}
+
+// Board selects the issues on an Agile board
+type Board struct {
+	Client *jira.Client
+	ID     string
+}
+
+// Name describes the board
+func (b *Board) Name() string {
+	return "board:" + b.ID
+}
+
+// Issues lists the board's issues
+func (b *Board) Issues() ([]jira.Issue, error) {
+	return b.Client.GetAllBoardIssues(b.ID)
+}
+
+// KeyFile selects issues listed in a text file, one key per line, or on
+// stdin if Path is "-". Blank lines and lines starting with # are ignored.
+type KeyFile struct {
+	Client *jira.Client
+	Path   string
+}
+
+// Name describes the key file
+func (k *KeyFile) Name() string {
+	return "keys:" + k.Path
+}
+
+// Issues reads the keys and resolves them to issues
+func (k *KeyFile) Issues() ([]jira.Issue, error) {
+	keys, err := ReadKeyFile(k.Path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveKeys(k.Client, k.Name(), keys)
+}
+
+// ReadKeyFile reads the entries of a key file, one per line, or of stdin if
+// path is "-". Blank lines and lines starting with # are ignored; entries
+// are returned as written.
+func ReadKeyFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open key file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var keys []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return keys, nil
+}
+
+// CSV selects issues listed in a column of a CSV file with a header row,
+// such as the export of a data warehouse query
+type CSV struct {
+	Client *jira.Client
+	Path   string
+	Column string
+}
+
+// Name describes the CSV source
+func (c *CSV) Name() string {
+	return fmt.Sprintf("csv:%s#%s", c.Path, c.Column)
+}
+
+// Issues reads the key column and resolves it to issues
+func (c *CSV) Issues() ([]jira.Issue, error) {
+	f, err := os.Open(c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer f.Close()
+
+	keys, err := readCSVColumn(f, c.Column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file %s: %w", c.Path, err)
+	}
+
+	return resolveKeys(c.Client, c.Name(), keys)
+}
+
+// readCSVColumn returns the non-empty values of the named column
+func readCSVColumn(r io.Reader, column string) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("column %q not found", column)
+	}
+
+	var values []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if index < len(record) {
+			if value := strings.TrimSpace(record[index]); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// resolveKeys validates and normalizes manually supplied keys, then fetches
+// their issues in chunks. Invalid entries fail the selection before any
+// request is sent.
+func resolveKeys(client *jira.Client, source string, raw []string) ([]jira.Issue, error) {
+	keys, invalid := jira.NormalizeKeys(raw)
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("%s has %d invalid issue keys: %s", source, len(invalid), strings.Join(quoteAll(invalid), ", "))
+	}
+	if dropped := len(raw) - len(keys); dropped > 0 {
+		client.Logger().Infof("%s: ignored %d duplicate issue keys", source, dropped)
+	}
+
+	var issues []jira.Issue
+	for i := 0; i < len(keys); i += keysPerQuery {
+		end := i + keysPerQuery
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		chunk, err := client.GetAllIssues(fmt.Sprintf("key in (%s)", strings.Join(keys[i:end], ",")))
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, chunk...)
+	}
+	return issues, nil
+}
+
+// quoteAll quotes each value so blank or odd entries stay visible in errors
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return quoted
+}
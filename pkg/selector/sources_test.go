@@ -0,0 +1,158 @@
+package selector
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// searchServer answers "key in (...)" searches with the listed issues and
+// records the queries it was sent
+type searchServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	queries []string
+}
+
+var keyInPattern = regexp.MustCompile(`key in \(([^)]*)\)`)
+
+func newSearchServer(t *testing.T) *searchServer {
+	t.Helper()
+	s := &searchServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jql := r.URL.Query().Get("jql")
+		s.mu.Lock()
+		s.queries = append(s.queries, jql)
+		s.mu.Unlock()
+
+		var result jira.SearchResult
+		if m := keyInPattern.FindStringSubmatch(jql); m != nil {
+			for _, key := range strings.Split(m[1], ",") {
+				result.Issues = append(result.Issues, jira.Issue{Key: key})
+			}
+		}
+		result.Total = len(result.Issues)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *searchServer) client() *jira.Client {
+	client := jira.NewClient(s.URL, "user@example.com", "token")
+	client.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return client
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func keysOf(issues []jira.Issue) []string {
+	keys := make([]string, len(issues))
+	for i, issue := range issues {
+		keys[i] = issue.Key
+	}
+	return keys
+}
+
+func TestReadKeyFile(t *testing.T) {
+	path := writeFile(t, "keys.txt", "# stale issues\nPROJ-1\n\n  proj-2  \r\n#PROJ-3\nPROJ-1\n")
+	keys, err := ReadKeyFile(path)
+	if err != nil {
+		t.Fatalf("ReadKeyFile() error = %v", err)
+	}
+	// Entries are returned as written; normalizing them is up to resolveKeys
+	if want := []string{"PROJ-1", "proj-2", "PROJ-1"}; !slices.Equal(keys, want) {
+		t.Errorf("ReadKeyFile() = %q, want %q", keys, want)
+	}
+
+	if _, err := ReadKeyFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ReadKeyFile(missing file) succeeded, want an error")
+	}
+}
+
+func TestKeyFileNormalizesKeys(t *testing.T) {
+	server := newSearchServer(t)
+	path := writeFile(t, "keys.txt", "proj-2\nPROJ-1\n PROJ-2 \n")
+
+	issues, err := (&KeyFile{Client: server.client(), Path: path}).Issues()
+	if err != nil {
+		t.Fatalf("Issues() error = %v", err)
+	}
+	if got, want := keysOf(issues), []string{"PROJ-2", "PROJ-1"}; !slices.Equal(got, want) {
+		t.Errorf("issues = %q, want %q", got, want)
+	}
+	if want := []string{"key in (PROJ-2,PROJ-1)"}; !slices.Equal(server.queries, want) {
+		t.Errorf("queries = %q, want %q", server.queries, want)
+	}
+}
+
+func TestKeyFileRejectsInvalidKeysBeforeSearching(t *testing.T) {
+	server := newSearchServer(t)
+	path := writeFile(t, "keys.txt", "PROJ-1\nPROJ 2\nPROJ-3) OR project = OPS\n")
+
+	_, err := (&KeyFile{Client: server.client(), Path: path}).Issues()
+	if err == nil || !strings.Contains(err.Error(), `2 invalid issue keys: "PROJ 2", "PROJ-3) OR project = OPS"`) {
+		t.Errorf("Issues() error = %v, want both invalid entries listed", err)
+	}
+	if len(server.queries) > 0 {
+		t.Errorf("queries = %q, want none", server.queries)
+	}
+}
+
+func TestResolveKeysInChunks(t *testing.T) {
+	server := newSearchServer(t)
+	raw := make([]string, keysPerQuery+5)
+	for i := range raw {
+		raw[i] = "PROJ-" + strconv.Itoa(i+1)
+	}
+
+	issues, err := resolveKeys(server.client(), "test", raw)
+	if err != nil {
+		t.Fatalf("resolveKeys() error = %v", err)
+	}
+	if len(issues) != len(raw) {
+		t.Errorf("got %d issues, want %d", len(issues), len(raw))
+	}
+	if len(server.queries) != 2 {
+		t.Errorf("sent %d queries, want 2 for %d keys", len(server.queries), len(raw))
+	}
+}
+
+func TestCSVColumn(t *testing.T) {
+	server := newSearchServer(t)
+	path := writeFile(t, "export.csv", "summary, Issue Key ,status\n\"Old, done\",proj-1,Done\nNo key,,Done\nShort row\nRecent,PROJ-4,Done\n")
+
+	issues, err := (&CSV{Client: server.client(), Path: path, Column: "issue key"}).Issues()
+	if err != nil {
+		t.Fatalf("Issues() error = %v", err)
+	}
+	if got, want := keysOf(issues), []string{"PROJ-1", "PROJ-4"}; !slices.Equal(got, want) {
+		t.Errorf("issues = %q, want %q", got, want)
+	}
+
+	_, err = (&CSV{Client: server.client(), Path: path, Column: "key"}).Issues()
+	if err == nil || !strings.Contains(err.Error(), `column "key" not found`) {
+		t.Errorf("Issues() with a missing column error = %v, want column not found", err)
+	}
+}
@@ -0,0 +1,163 @@
+package warehouse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+const (
+	bigQueryAPIURL = "https://bigquery.googleapis.com/bigquery/v2"
+	// metadataTokenURL returns the token of the service account attached to
+	// a GCE VM, GKE pod or Cloud Run job
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// bigQueryBatchSize keeps insertAll requests well below the API's limits
+	bigQueryBatchSize = 500
+)
+
+// BigQuery streams rows into a table through the tabledata.insertAll API.
+// Each row's insert ID is its run ID and issue key, so a retried load does
+// not duplicate rows.
+type BigQuery struct {
+	project, dataset, table string
+	token                   string
+	httpClient              *http.Client
+}
+
+// NewBigQuery creates a BigQuery sink for a table given as
+// project.dataset.table. Without a token it asks the metadata server for
+// one on every load.
+func NewBigQuery(table, token string) (*BigQuery, error) {
+	parts := strings.Split(table, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("BigQuery table must be project.dataset.table, not %q", table)
+	}
+	return &BigQuery{
+		project:    parts[0],
+		dataset:    parts[1],
+		table:      parts[2],
+		token:      token,
+		httpClient: transport.Client(60 * time.Second),
+	}, nil
+}
+
+type insertAllRow struct {
+	InsertID string `json:"insertId"`
+	JSON     Row    `json:"json"`
+}
+
+type insertAllResponse struct {
+	InsertErrors []struct {
+		Index  int `json:"index"`
+		Errors []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"insertErrors"`
+}
+
+// Load inserts the rows in batches; rows BigQuery rejects are reported in
+// the error
+func (b *BigQuery) Load(rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	token := b.token
+	if token == "" {
+		var err error
+		if token, err = b.metadataToken(); err != nil {
+			return fmt.Errorf("no BigQuery token: %w", err)
+		}
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", bigQueryAPIURL,
+		url.PathEscape(b.project), url.PathEscape(b.dataset), url.PathEscape(b.table))
+	for start := 0; start < len(rows); start += bigQueryBatchSize {
+		end := min(start+bigQueryBatchSize, len(rows))
+		if err := b.insert(endpoint, token, rows[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BigQuery) insert(endpoint, token string, rows []Row) error {
+	request := struct {
+		Rows []insertAllRow `json:"rows"`
+	}{}
+	for _, r := range rows {
+		request.Rows = append(request.Rows, insertAllRow{InsertID: r.RunID + "/" + r.IssueKey, JSON: r})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("BigQuery returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	var result insertAllResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.InsertErrors) > 0 {
+		first := result.InsertErrors[0]
+		reason := "unknown error"
+		if len(first.Errors) > 0 {
+			reason = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		if first.Index >= 0 && first.Index < len(rows) {
+			reason = rows[first.Index].IssueKey + ": " + reason
+		}
+		return fmt.Errorf("BigQuery rejected %d of %d rows (%s)", len(result.InsertErrors), len(rows), reason)
+	}
+	return nil
+}
+
+// metadataToken fetches an access token for the attached service account
+func (b *BigQuery) metadataToken() (string, error) {
+	req, err := http.NewRequest("GET", metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := transport.Client(5 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata server unavailable (a token is required outside Google Cloud): %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	return token.AccessToken, nil
+}
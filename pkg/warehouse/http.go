@@ -0,0 +1,123 @@
+package warehouse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/transport"
+)
+
+// csvHeader is the header row of the csv format, in Row field order
+var csvHeader = []string{
+	"run_id", "started_at", "finished_at", "selector", "label", "policy_hash",
+	"project", "issue_key", "outcome", "permanent", "error", "aborted",
+}
+
+// HTTP sends the rows of a run in a single request, as NDJSON or CSV. It
+// suits loader endpoints in front of a warehouse and signed bucket URLs
+// that a load job picks up.
+type HTTP struct {
+	url        string
+	method     string
+	format     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewHTTP creates an HTTP sink. An empty method uses POST and an empty
+// format ndjson.
+func NewHTTP(url, method, format, token string) (*HTTP, error) {
+	if url == "" {
+		return nil, fmt.Errorf("the http warehouse sink needs a URL")
+	}
+	if method == "" {
+		method = http.MethodPost
+	}
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		return nil, fmt.Errorf("unknown warehouse format %q (use ndjson or csv)", format)
+	}
+	return &HTTP{
+		url:        url,
+		method:     strings.ToUpper(method),
+		format:     format,
+		token:      token,
+		httpClient: transport.Client(60 * time.Second),
+	}, nil
+}
+
+// Load sends the rows and treats any 2xx status as success
+func (h *HTTP) Load(rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	body, contentType, err := h.encode(rows)
+	if err != nil {
+		return err
+	}
+
+	url := strings.ReplaceAll(h.url, "{run_id}", rows[0].RunID)
+	req, err := http.NewRequest(h.method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("warehouse returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func (h *HTTP) encode(rows []Row) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if h.format == "csv" {
+		w := csv.NewWriter(&buf)
+		w.Write(csvHeader)
+		for _, r := range rows {
+			w.Write([]string{
+				r.RunID,
+				r.StartedAt.Format(time.RFC3339),
+				r.FinishedAt.Format(time.RFC3339),
+				r.Selector,
+				r.Label,
+				r.PolicyHash,
+				r.Project,
+				r.IssueKey,
+				r.Outcome,
+				strconv.FormatBool(r.Permanent),
+				r.Error,
+				strconv.FormatBool(r.Aborted),
+			})
+		}
+		w.Flush()
+		return buf.Bytes(), "text/csv", w.Error()
+	}
+
+	enc := json.NewEncoder(&buf)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return nil, "", err
+		}
+	}
+	return buf.Bytes(), "application/x-ndjson", nil
+}
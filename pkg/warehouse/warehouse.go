@@ -0,0 +1,102 @@
+// Package warehouse loads the per-issue outcomes of a run into a data
+// warehouse, so archival KPIs can be tracked next to other analytics.
+package warehouse
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// Outcomes of a Row
+const (
+	OutcomeArchived = "archived"
+	OutcomeFailed   = "failed"
+	OutcomeSkipped  = "skipped"
+)
+
+// Row is the outcome of one issue in one run. The JSON names are the
+// column names of the warehouse table.
+type Row struct {
+	RunID      string    `json:"run_id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Selector   string    `json:"selector"`
+	Label      string    `json:"label"`
+	PolicyHash string    `json:"policy_hash"`
+	Project    string    `json:"project"`
+	IssueKey   string    `json:"issue_key"`
+	Outcome    string    `json:"outcome"`
+	Permanent  bool      `json:"permanent"`
+	Error      string    `json:"error"`
+	// Aborted is set on every row of a run that stopped early
+	Aborted bool `json:"aborted"`
+}
+
+// Sink loads rows into a warehouse table
+type Sink interface {
+	Load(rows []Row) error
+}
+
+// Rows returns one row per issue processed in the run
+func Rows(result *worker.RunResult) []Row {
+	rows := make([]Row, 0, len(result.Results))
+	for _, r := range result.Results {
+		row := Row{
+			RunID:      result.RunID,
+			StartedAt:  result.StartedAt.UTC(),
+			FinishedAt: result.FinishedAt.UTC(),
+			Selector:   result.Selector,
+			Label:      result.Label,
+			PolicyHash: result.PolicyHash,
+			Project:    jira.KeyProject(r.IssueKey),
+			IssueKey:   r.IssueKey,
+			Aborted:    result.Stopped(),
+		}
+		switch {
+		case r.Success:
+			row.Outcome = OutcomeArchived
+		case r.Skipped:
+			row.Outcome = OutcomeSkipped
+		default:
+			row.Outcome = OutcomeFailed
+			row.Permanent = r.Permanent
+		}
+		if r.Error != nil {
+			row.Error = r.Error.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Options configures the sink returned by New
+type Options struct {
+	// Table is the BigQuery table as project.dataset.table
+	Table string
+	// URL receives the rows of the http sink. {run_id} is replaced with the
+	// run ID, e.g. to name the object behind a signed bucket URL.
+	URL string
+	// Method is the HTTP method of the http sink (default POST)
+	Method string
+	// Format is ndjson (default) or csv
+	Format string
+	// Token is sent as a bearer token. The bigquery sink falls back to the
+	// metadata server's service account token when it is empty.
+	Token string
+}
+
+// New returns the sink for kind: "bigquery" streams the rows into a
+// BigQuery table, "http" sends them to a URL as NDJSON or CSV
+func New(kind string, opts Options) (Sink, error) {
+	switch kind {
+	case "bigquery":
+		return NewBigQuery(opts.Table, opts.Token)
+	case "http":
+		return NewHTTP(opts.URL, opts.Method, opts.Format, opts.Token)
+	default:
+		return nil, fmt.Errorf("unknown warehouse sink %q (use bigquery or http)", kind)
+	}
+}
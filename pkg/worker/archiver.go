@@ -1,61 +1,394 @@
 package worker
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/export"
 	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/logging"
 )
 
 // ArchiveResult represents the result of archiving an issue
 type ArchiveResult struct {
 	IssueKey string
+	Summary  string
 	Success  bool
-	Error    error
+	// Skipped issues were filtered out before the archive call; Error holds the reason
+	Skipped bool
+	// Permanent failures will not succeed on retry, e.g. unsupported issue types
+	Permanent bool
+	Error     error
+
+	// Batch is the 1-based number of the batch the issue was processed in,
+	// and ProcessedAt when that batch finished
+	Batch       int
+	ProcessedAt time.Time
+}
+
+// MarshalJSON encodes the error as its message
+func (r ArchiveResult) MarshalJSON() ([]byte, error) {
+	out := struct {
+		IssueKey    string `json:"issueKey"`
+		Summary     string `json:"summary,omitempty"`
+		Success     bool   `json:"success"`
+		Skipped     bool   `json:"skipped,omitempty"`
+		Permanent   bool   `json:"permanent,omitempty"`
+		Error       string `json:"error,omitempty"`
+		Batch       int    `json:"batch,omitempty"`
+		ProcessedAt string `json:"processedAt,omitempty"`
+	}{IssueKey: r.IssueKey, Summary: r.Summary, Success: r.Success, Skipped: r.Skipped, Permanent: r.Permanent, Batch: r.Batch}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	if !r.ProcessedAt.IsZero() {
+		out.ProcessedAt = r.ProcessedAt.Format(time.RFC3339)
+	}
+	return json.Marshal(out)
 }
 
+// ErrFailureRateExceeded is returned when a run is aborted because too many
+// issues failed, which usually indicates systemic breakage
+var ErrFailureRateExceeded = errors.New("failure rate threshold exceeded")
+
+// ErrStoppedOnBudget is returned when the run stopped cleanly because the
+// client's API call budget was used up
+var ErrStoppedOnBudget = errors.New("stopped: API call budget exhausted")
+
+// ErrCanaryFailed is returned when the canary batch did not archive cleanly
+var ErrCanaryFailed = errors.New("canary batch failed")
+
 // Archiver handles bulk archiving of JIRA issues
 type Archiver struct {
 	client    *jira.Client
 	batchSize int
+	progress  *Progress
+	warnings  *Warnings
+	logger    logging.Logger
+
+	// Abort once the cumulative failure percentage exceeds abortRate
+	// after at least abortMinBatches batches (abortRate 0 disables)
+	abortRate       float64
+	abortMinBatches int
+
+	// Archive and verify this many issues first (0 disables)
+	canarySize int
+
+	// Verify every batch, not just the canary, with bounded concurrency
+	verifyAll         bool
+	verifyConcurrency int
+
+	// Filter predictable rejections before each batch
+	preflight          bool
+	projectPermissions map[string]bool
+
+	// Skip issues by issue security level
+	skipSecured    bool
+	securityLevels []string
+
+	// Skip all or unresolved service desk requests
+	serviceDeskPolicy string
+
+	// Never archive issues with these labels, statuses or issue types
+	exclusions Exclusions
+
+	// Never mix projects within a batch
+	partitionByProject bool
+
+	// Identify issues by ID rather than key in archive requests
+	archiveByID bool
+
+	// Export the full JSON of issues before archiving them (nil disables)
+	exporter          export.Writer
+	exportConcurrency int
+
+	// Comment on issues before archiving them (nil disables)
+	commenter *commenter
+
+	// Labels applied to and removed from permanently failed issues
+	failureLabel string
+	triggerLabel string
+
+	// Entity property recording the run on each issue (empty key disables)
+	propertyKey string
+	property    RunProperty
+
+	// Called with the results of every finished batch (nil disables)
+	batchHook func(batch int, results []ArchiveResult)
 }
 
 // NewArchiver creates a new Archiver
 func NewArchiver(client *jira.Client, _ int) *Archiver {
 	return &Archiver{
-		client:    client,
-		batchSize: 1000, // Archive up to 1000 issues per batch
+		client:             client,
+		batchSize:          jira.MaxArchiveIssues,
+		projectPermissions: make(map[string]bool),
+		partitionByProject: true,
+		verifyConcurrency:  4,
 	}
 }
 
-// ArchiveIssues archives multiple issues using bulk API
-func (a *Archiver) ArchiveIssues(issues []jira.Issue) []ArchiveResult {
+// SetBatchHook calls hook with the 1-based number and the results of each
+// batch once it has finished, e.g. to checkpoint the run
+func (a *Archiver) SetBatchHook(hook func(batch int, results []ArchiveResult)) {
+	a.batchHook = hook
+}
+
+// SetProgress enables machine-readable progress events
+func (a *Archiver) SetProgress(progress *Progress) {
+	a.progress = progress
+}
+
+// SetLogger sends the archiver's logs to logger instead of the standard logger
+func (a *Archiver) SetLogger(logger *slog.Logger) {
+	a.logger = logging.NewLogger(logger)
+}
+
+// SetWarnings collects the run's warnings in warnings
+func (a *Archiver) SetWarnings(warnings *Warnings) {
+	a.warnings = warnings
+}
+
+// SetAbortThreshold aborts the run when more than ratePercent of the issues
+// processed so far have failed, checked after minBatches batches
+func (a *Archiver) SetAbortThreshold(ratePercent float64, minBatches int) {
+	a.abortRate = ratePercent
+	a.abortMinBatches = minBatches
+}
+
+// SetPartitionByProject controls whether batches are split per project, so a
+// project-level permission problem only fails that project's batches
+func (a *Archiver) SetPartitionByProject(enabled bool) {
+	a.partitionByProject = enabled
+}
+
+// SetArchiveByID controls whether archive requests identify issues by ID.
+// Keys are still used in logs and results.
+func (a *Archiver) SetArchiveByID(enabled bool) {
+	a.archiveByID = enabled
+}
+
+// SetBatchSize sets how many issues are sent per bulk archive request.
+// The API accepts at most jira.MaxArchiveIssues.
+func (a *Archiver) SetBatchSize(size int) {
+	a.batchSize = size
+}
+
+// SetCanary archives and verifies size issues before the rest of the run
+func (a *Archiver) SetCanary(size int) {
+	a.canarySize = size
+}
+
+// ArchiveIssues archives multiple issues using bulk API. When the abort
+// threshold is hit or the canary batch fails, it returns the results so
+// far together with ErrFailureRateExceeded or ErrCanaryFailed.
+func (a *Archiver) ArchiveIssues(issues []jira.Issue) ([]ArchiveResult, error) {
+	return a.ArchiveIssuesContext(context.Background(), issues)
+}
+
+// ArchiveIssuesContext is ArchiveIssues, stopping before the next batch once
+// ctx is done. The results so far are returned together with ctx's error.
+// A batch already sent is not cancelled, so every processed issue has a
+// known outcome.
+func (a *Archiver) ArchiveIssuesContext(ctx context.Context, issues []jira.Issue) ([]ArchiveResult, error) {
 	totalIssues := len(issues)
 	if totalIssues == 0 {
-		log.Println("No issues to archive")
-		return []ArchiveResult{}
+		a.logger.Infof("No issues to archive\n")
+		return []ArchiveResult{}, nil
 	}
 
-	log.Printf("Starting to archive %d issues using bulk API (batch size: %d)\n", totalIssues, a.batchSize)
+	a.logger.Infof("Starting to archive %d issues using bulk API (batch size: %d)\n", totalIssues, a.batchSize)
 
-	// Split issues into batches
-	batches := a.createBatches(issues)
-	log.Printf("Created %d batches\n", len(batches))
+	batches, hasCanary := a.batches(issues)
+	a.logger.Infof("Created %d batches\n", len(batches))
+
+	counts := ProgressEvent{Total: totalIssues, Batches: len(batches)}
+	a.emit(EventRunStarted, counts)
 
 	// Process each batch sequentially
 	var allResults []ArchiveResult
 	for batchNum, batch := range batches {
-		log.Printf("Processing batch %d/%d (%d issues)\n", batchNum+1, len(batches), len(batch))
-		batchResults := a.processBatch(batch)
+		if a.client.BudgetExhausted() {
+			return a.stopOnBudget(allResults, counts)
+		}
+		if err := ctx.Err(); err != nil {
+			a.logger.Warnf("Stopping run: %v, %d issues not processed\n", err, totalIssues-counts.Processed)
+			counts.Batch = 0
+			a.emit(EventRunFinished, counts)
+			return allResults, err
+		}
+
+		a.logger.Infof("Processing batch %d/%d (%d issues)\n", batchNum+1, len(batches), len(batch))
+		counts.Batch = batchNum + 1
+		a.emit(EventBatchStarted, counts)
+
+		isCanary := hasCanary && batchNum == 0
+		if isCanary {
+			a.logger.Infof("Archiving canary batch of %d issues before the full run\n", len(batch))
+		}
+
+		var batchResults []ArchiveResult
+		if a.checksEligibility() {
+			var skipped []ArchiveResult
+			batch, skipped = a.checkEligibility(batch)
+			batchResults = append(batchResults, skipped...)
+		}
+
+		if a.exporter != nil && len(batch) > 0 {
+			var failed []ArchiveResult
+			var err error
+			batch, failed, err = a.exportBatch(batch)
+			if err != nil {
+				// The batch was never sent; report it as not processed
+				allResults = append(allResults, batchResults...)
+				a.countResults(batchResults, &counts)
+				return a.stopOnBudget(allResults, counts)
+			}
+			batchResults = append(batchResults, failed...)
+		}
+
+		if len(batch) > 0 {
+			archived, err := a.processBatch(context.WithoutCancel(ctx), batch)
+			if errors.Is(err, jira.ErrAPIBudgetExhausted) {
+				// The batch was never sent; report it as not processed
+				allResults = append(allResults, batchResults...)
+				a.countResults(batchResults, &counts)
+				return a.stopOnBudget(allResults, counts)
+			}
+			if isCanary || a.verifyAll {
+				a.verifyArchived(batch, archived)
+			}
+			batchResults = append(batchResults, archived...)
+		}
+		processedAt := time.Now()
+		for i := range batchResults {
+			batchResults[i].Batch = batchNum + 1
+			batchResults[i].ProcessedAt = processedAt
+		}
+		a.labelPermanentFailures(batchResults)
 		allResults = append(allResults, batchResults...)
+		if a.batchHook != nil {
+			a.batchHook(batchNum+1, batchResults)
+		}
+
+		a.countResults(batchResults, &counts)
+		a.emit(EventBatchFinished, counts)
+
+		if isCanary && counts.Failed > 0 {
+			a.logger.Warnf("Aborting run: %d of %d canary issues failed, %d issues not processed\n",
+				counts.Failed, counts.Processed, totalIssues-counts.Processed)
+			counts.Batch = 0
+			a.emit(EventRunFinished, counts)
+			return allResults, fmt.Errorf("%w: %d of %d issues failed", ErrCanaryFailed, counts.Failed, counts.Processed)
+		}
+
+		if a.shouldAbort(batchNum+1, counts) {
+			a.logger.Warnf("Aborting run: %d of %d processed issues failed (threshold %.1f%%), %d issues not processed\n",
+				counts.Failed, counts.Processed, a.abortRate, totalIssues-counts.Processed)
+			counts.Batch = 0
+			a.emit(EventRunFinished, counts)
+			return allResults, fmt.Errorf("%w: %d of %d processed issues failed", ErrFailureRateExceeded, counts.Failed, counts.Processed)
+		}
 	}
 
-	return allResults
+	counts.Batch = 0
+	a.emit(EventRunFinished, counts)
+
+	return allResults, nil
+}
+
+// countResults updates the running counters and emits per-issue events
+func (a *Archiver) countResults(results []ArchiveResult, counts *ProgressEvent) {
+	for _, result := range results {
+		counts.Processed++
+		issueEvent := *counts
+		issueEvent.IssueKey = result.IssueKey
+		if result.Success {
+			counts.Succeeded++
+			issueEvent.Succeeded++
+			a.emit(EventIssueArchived, issueEvent)
+		} else if result.Skipped {
+			counts.Skipped++
+			issueEvent.Skipped++
+			issueEvent.Error = result.Error.Error()
+			a.emit(EventIssueSkipped, issueEvent)
+		} else {
+			counts.Failed++
+			issueEvent.Failed++
+			issueEvent.Error = result.Error.Error()
+			a.emit(EventIssueFailed, issueEvent)
+		}
+	}
+}
+
+// stopOnBudget ends the run cleanly once the API call budget is used up
+func (a *Archiver) stopOnBudget(results []ArchiveResult, counts ProgressEvent) ([]ArchiveResult, error) {
+	remaining := counts.Total - counts.Processed
+	a.logger.Warnf("Stopping run: API call budget exhausted after %d API calls, %d issues not processed\n", a.client.APICalls(), remaining)
+	counts.Batch = 0
+	a.emit(EventRunFinished, counts)
+	return results, fmt.Errorf("%w: %d issues not processed", ErrStoppedOnBudget, remaining)
 }
 
-// createBatches splits issues into batches of configured size
+// shouldAbort checks the cumulative failure rate against the abort threshold
+func (a *Archiver) shouldAbort(batchesDone int, counts ProgressEvent) bool {
+	if a.abortRate <= 0 || batchesDone < a.abortMinBatches || counts.Processed == 0 {
+		return false
+	}
+	// Nothing left to protect after the last batch
+	if counts.Processed == counts.Total {
+		return false
+	}
+	return float64(counts.Failed)*100/float64(counts.Processed) > a.abortRate
+}
+
+// emit sends a progress event with the given type and counters
+func (a *Archiver) emit(eventType string, counts ProgressEvent) {
+	counts.Event = eventType
+	a.progress.Emit(counts)
+}
+
+// batches splits issues into batches, with the canary batch first if enabled
+func (a *Archiver) batches(issues []jira.Issue) ([][]jira.Issue, bool) {
+	if a.canarySize > 0 && len(issues) > a.canarySize {
+		return append([][]jira.Issue{issues[:a.canarySize]}, a.createBatches(issues[a.canarySize:])...), true
+	}
+	return a.createBatches(issues), false
+}
+
+// createBatches splits issues into batches of configured size, one project
+// at a time when partitioning is enabled
 func (a *Archiver) createBatches(issues []jira.Issue) [][]jira.Issue {
+	if !a.partitionByProject {
+		return a.chunk(issues)
+	}
+
+	// Group by project, keeping projects in the order they were first seen
+	var projects []string
+	groups := make(map[string][]jira.Issue)
+	for _, issue := range issues {
+		project := projectKeyOf(issue)
+		if _, ok := groups[project]; !ok {
+			projects = append(projects, project)
+		}
+		groups[project] = append(groups[project], issue)
+	}
+
+	var batches [][]jira.Issue
+	for _, project := range projects {
+		batches = append(batches, a.chunk(groups[project])...)
+	}
+	return batches
+}
+
+// chunk splits issues into consecutive batches of configured size
+func (a *Archiver) chunk(issues []jira.Issue) [][]jira.Issue {
 	var batches [][]jira.Issue
 	for i := 0; i < len(issues); i += a.batchSize {
 		end := i + a.batchSize
@@ -67,75 +400,142 @@ func (a *Archiver) createBatches(issues []jira.Issue) [][]jira.Issue {
 	return batches
 }
 
-// processBatch processes a single batch of issues using the bulk archive API
-func (a *Archiver) processBatch(batch []jira.Issue) []ArchiveResult {
+// projectKeyOf returns the issue's project key, falling back to the key prefix
+func projectKeyOf(issue jira.Issue) string {
+	if issue.Fields.Project != nil && issue.Fields.Project.Key != "" {
+		return issue.Fields.Project.Key
+	}
+	if i := strings.LastIndex(issue.Key, "-"); i > 0 {
+		return issue.Key[:i]
+	}
+	return ""
+}
+
+// processBatch processes a single batch of issues using the bulk archive API.
+// It returns jira.ErrAPIBudgetExhausted, without results, if the archive
+// call could not be sent.
+func (a *Archiver) processBatch(ctx context.Context, batch []jira.Issue) ([]ArchiveResult, error) {
 	batchSize := len(batch)
-	issueKeys := make([]string, batchSize)
+	issueRefs := make([]string, batchSize)
 
 	for i, issue := range batch {
-		issueKeys[i] = issue.Key
-		log.Printf("Batch item %d: Key=%s, ID=%s\n", i, issue.Key, issue.ID)
+		issueRefs[i] = a.issueRef(issue)
+		a.logger.Debugf("Batch item %d: Key=%s, ID=%s\n", i, issue.Key, issue.ID)
+	}
+
+	if a.commenter != nil {
+		a.commentBatch(batch)
+	}
+	if a.propertyKey != "" {
+		a.tagBatch(batch)
 	}
 
-	log.Printf("Archiving batch of %d issues\n", batchSize)
+	a.logger.Infof("Archiving batch of %d issues\n", batchSize)
 
 	// Call bulk archive API
-	resp, err := a.client.ArchiveIssues(issueKeys)
+	resp, err := a.client.ArchiveIssuesContext(ctx, issueRefs)
+	if errors.Is(err, jira.ErrAPIBudgetExhausted) {
+		return nil, err
+	}
+
+	// Index rejections by key whichever reference was sent
+	issueErrors := make(map[string]jira.IssueError)
+	if resp != nil {
+		rejected := resp.IssueErrors()
+		for i, issue := range batch {
+			if issueErr, ok := rejected[issueRefs[i]]; ok {
+				issueErrors[issue.Key] = issueErr
+			}
+		}
+		if !a.archiveByID {
+			a.retryMovedByID(batch, issueErrors)
+		}
+	}
 
 	// Process results
 	batchResults := make([]ArchiveResult, batchSize)
 	for i, issue := range batch {
+		issueErr, rejected := issueErrors[issue.Key]
 		if err != nil {
 			// Entire batch failed
 			batchResults[i] = ArchiveResult{
 				IssueKey: issue.Key,
+				Summary:  issue.Fields.Summary,
 				Success:  false,
 				Error:    err,
 			}
-			log.Printf("Failed to archive %s: %v\n", issue.Key, err)
-		} else if resp != nil && resp.Errors != nil && resp.Errors[issue.Key] != "" {
+			a.logger.Warnf("Failed to archive %s: %v\n", issue.Key, err)
+		} else if rejected {
 			// Individual issue failed
 			batchResults[i] = ArchiveResult{
-				IssueKey: issue.Key,
-				Success:  false,
-				Error:    fmt.Errorf("%s", resp.Errors[issue.Key]),
+				IssueKey:  issue.Key,
+				Summary:   issue.Fields.Summary,
+				Success:   false,
+				Permanent: issueErr.IsPermanent(),
+				Error:     fmt.Errorf("%s", issueErr.Message),
 			}
-			log.Printf("Failed to archive %s: %s\n", issue.Key, resp.Errors[issue.Key])
+			a.logger.Warnf("Failed to archive %s: %s\n", issue.Key, issueErr.Message)
 		} else {
 			// Success
 			batchResults[i] = ArchiveResult{
 				IssueKey: issue.Key,
+				Summary:  issue.Fields.Summary,
 				Success:  true,
 				Error:    nil,
 			}
-			log.Printf("Successfully archived %s\n", issue.Key)
+			a.logger.Debugf("Successfully archived %s\n", issue.Key)
+		}
+	}
+
+	archived := 0
+	for _, result := range batchResults {
+		if result.Success {
+			archived++
 		}
 	}
+	a.logger.Infof("Archived %d of %d issues in batch\n", archived, batchSize)
+	return batchResults, nil
+}
 
-	return batchResults
+// issueRef returns the ID or key identifying issue in archive requests
+func (a *Archiver) issueRef(issue jira.Issue) string {
+	if a.archiveByID && issue.ID != "" {
+		return issue.ID
+	}
+	return issue.Key
 }
 
-// PrintSummary prints a summary of the archive operation
-func PrintSummary(results []ArchiveResult) {
-	total := len(results)
-	successful := 0
-	failed := 0
+// SortResults returns a copy of results ordered by issue key. Results are
+// produced in batch order, which the canary and project partitioning change.
+func SortResults(results []ArchiveResult) []ArchiveResult {
+	sorted := append([]ArchiveResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return jira.CompareKeys(sorted[i].IssueKey, sorted[j].IssueKey) < 0
+	})
+	return sorted
+}
 
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("Archive Summary")
-	fmt.Println(strings.Repeat("=", 50))
+// Summary is the outcome of a run, as rendered by the summary template
+type Summary struct {
+	// Results ordered by issue key
+	Results   []ArchiveResult `json:"results"`
+	Total     int             `json:"total"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Skipped   int             `json:"skipped"`
+}
 
+// Summarize counts the results of a run
+func Summarize(results []ArchiveResult) Summary {
+	summary := Summary{Results: SortResults(results), Total: len(results)}
 	for _, result := range results {
 		if result.Success {
-			successful++
+			summary.Succeeded++
+		} else if result.Skipped {
+			summary.Skipped++
 		} else {
-			failed++
-			fmt.Printf("Failed: %s - %v\n", result.IssueKey, result.Error)
+			summary.Failed++
 		}
 	}
-
-	fmt.Printf("\nTotal issues: %d\n", total)
-	fmt.Printf("Successfully archived: %d\n", successful)
-	fmt.Printf("Failed: %d\n", failed)
-	fmt.Println(strings.Repeat("=", 50))
+	return summary
 }
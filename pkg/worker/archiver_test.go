@@ -0,0 +1,155 @@
+package worker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+func issues(keys ...string) []jira.Issue {
+	list := make([]jira.Issue, len(keys))
+	for i, key := range keys {
+		list[i] = testIssue(key)
+	}
+	return list
+}
+
+func resultKeys(results []worker.ArchiveResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = r.IssueKey
+	}
+	return keys
+}
+
+func TestArchiveIssuesBatchesAndResults(t *testing.T) {
+	server := newRejectingServer(t, map[string]string{"PROJ-3": jira.ArchiveErrorIssueIsSubtask})
+	archiver := newTestArchiver(server.URL)
+	archiver.SetBatchSize(2)
+
+	results, err := archiver.ArchiveIssues(issues("PROJ-1", "PROJ-2", "PROJ-3", "PROJ-4", "PROJ-5"))
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	if got, want := len(server.archive), 3; got != want {
+		t.Errorf("sent %d archive requests %v, want %d", got, server.archive, want)
+	}
+	for i, r := range results {
+		if want := i/2 + 1; r.Batch != want {
+			t.Errorf("%s in batch %d, want %d", r.IssueKey, r.Batch, want)
+		}
+		if r.ProcessedAt.IsZero() {
+			t.Errorf("%s has no processing time", r.IssueKey)
+		}
+	}
+
+	summary := worker.Summarize(results)
+	if summary.Total != 5 || summary.Succeeded != 4 || summary.Failed != 1 || summary.Skipped != 0 {
+		t.Errorf("summary = %+v, want 4 of 5 archived and 1 failure", summary)
+	}
+	if failed := results[2]; failed.Success || failed.Error == nil || failed.Error.Error() != jira.ArchiveErrorIssueIsSubtask {
+		t.Errorf("PROJ-3 = %+v, want the archive API's error message", failed)
+	}
+}
+
+func TestArchiveIssuesPartitionByProject(t *testing.T) {
+	server := newRejectingServer(t, nil)
+	archiver := newTestArchiver(server.URL)
+	archiver.SetBatchSize(10)
+	archiver.SetPartitionByProject(true)
+
+	list := issues("PROJ-1", "OPS-1", "PROJ-2", "OPS-2")
+	list[1].Fields.Project = &jira.Project{Key: "OPS"}
+	list[3].Fields.Project = &jira.Project{Key: "OPS"}
+	results, err := archiver.ArchiveIssues(list)
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	want := [][]string{{"PROJ-1", "PROJ-2"}, {"OPS-1", "OPS-2"}}
+	if !slices.EqualFunc(server.archive, want, slices.Equal) {
+		t.Errorf("archive requests = %v, want %v", server.archive, want)
+	}
+	if got, want := resultKeys(worker.SortResults(results)), []string{"OPS-1", "OPS-2", "PROJ-1", "PROJ-2"}; !slices.Equal(got, want) {
+		t.Errorf("sorted results = %v, want %v", got, want)
+	}
+}
+
+func TestArchiveIssuesFailedBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorMessages":["Internal server error"]}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	archiver := newTestArchiver(server.URL)
+
+	results, err := archiver.ArchiveIssues(issues("PROJ-1", "PROJ-2"))
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	for _, r := range results {
+		if r.Success || r.Skipped || r.Permanent || r.Error == nil {
+			t.Errorf("%s = %+v, want a failure that is not permanent", r.IssueKey, r)
+		}
+	}
+}
+
+func TestArchiveIssuesCanaryFailureStopsRun(t *testing.T) {
+	server := newRejectingServer(t, map[string]string{"PROJ-2": jira.ArchiveErrorIssueIsSubtask})
+	archiver := newTestArchiver(server.URL)
+	archiver.SetCanary(2)
+
+	results, err := archiver.ArchiveIssues(issues("PROJ-1", "PROJ-2", "PROJ-3", "PROJ-4"))
+	if !errors.Is(err, worker.ErrCanaryFailed) {
+		t.Fatalf("ArchiveIssues() error = %v, want ErrCanaryFailed", err)
+	}
+	if got, want := resultKeys(results), []string{"PROJ-1", "PROJ-2"}; !slices.Equal(got, want) {
+		t.Errorf("results for %v, want only the canary %v", got, want)
+	}
+	if len(server.archive) != 1 {
+		t.Errorf("sent %d archive requests, want only the canary's", len(server.archive))
+	}
+}
+
+func TestArchiveIssuesAbortThreshold(t *testing.T) {
+	server := newRejectingServer(t, map[string]string{
+		"PROJ-1": jira.ArchiveErrorIssueIsSubtask,
+		"PROJ-2": jira.ArchiveErrorIssueIsSubtask,
+	})
+	archiver := newTestArchiver(server.URL)
+	archiver.SetBatchSize(2)
+	archiver.SetAbortThreshold(50, 1)
+
+	results, err := archiver.ArchiveIssues(issues("PROJ-1", "PROJ-2", "PROJ-3", "PROJ-4"))
+	if !errors.Is(err, worker.ErrFailureRateExceeded) {
+		t.Fatalf("ArchiveIssues() error = %v, want ErrFailureRateExceeded", err)
+	}
+	if len(results) != 2 || len(server.archive) != 1 {
+		t.Errorf("got %d results from %d requests, want the first batch only", len(results), len(server.archive))
+	}
+}
+
+func TestArchiveIssuesStopsOnBudget(t *testing.T) {
+	server := newRejectingServer(t, nil)
+	client := jira.NewClient(server.URL, "user@example.com", "token")
+	client.SetMaxAPICalls(1)
+	archiver := worker.NewArchiver(client, 0)
+	archiver.SetLogger(discardLogger())
+	archiver.SetBatchSize(2)
+
+	results, err := archiver.ArchiveIssues(issues("PROJ-1", "PROJ-2", "PROJ-3"))
+	if !errors.Is(err, worker.ErrStoppedOnBudget) {
+		t.Fatalf("ArchiveIssues() error = %v, want ErrStoppedOnBudget", err)
+	}
+	if got, want := resultKeys(results), []string{"PROJ-1", "PROJ-2"}; !slices.Equal(got, want) {
+		t.Errorf("results for %v, want %v; PROJ-3 was never sent", got, want)
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s = %+v, want archived", r.IssueKey, r)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// AuditCrossCheck compares our archive results with Jira's audit log
+type AuditCrossCheck struct {
+	// Issues we archived successfully without a matching audit record
+	MissingAudit []string `json:"missingAudit"`
+	// Archive audit records for issues we did not archive successfully
+	Unexpected []string `json:"unexpected"`
+	// Issues whose archive is confirmed by the audit log
+	Confirmed int `json:"confirmed"`
+}
+
+// HasMismatches reports whether the audit log disagrees with our results
+func (c *AuditCrossCheck) HasMismatches() bool {
+	return len(c.MissingAudit) > 0 || len(c.Unexpected) > 0
+}
+
+// CrossCheckAudit reconciles archive results against archive audit records
+func CrossCheckAudit(results []ArchiveResult, records []jira.AuditRecord) *AuditCrossCheck {
+	audited := make(map[string]bool)
+	for _, record := range records {
+		if !isArchiveRecord(record) {
+			continue
+		}
+		audited[record.ObjectItem.Name] = true
+		for _, item := range record.AssociatedItems {
+			audited[item.Name] = true
+		}
+	}
+
+	check := &AuditCrossCheck{}
+	archived := make(map[string]bool)
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		archived[result.IssueKey] = true
+		if audited[result.IssueKey] {
+			check.Confirmed++
+		} else {
+			check.MissingAudit = append(check.MissingAudit, result.IssueKey)
+		}
+	}
+
+	// Only flag audited names that belong to this run's issues
+	for _, result := range results {
+		if !archived[result.IssueKey] && audited[result.IssueKey] {
+			check.Unexpected = append(check.Unexpected, result.IssueKey)
+		}
+	}
+
+	jira.SortKeys(check.MissingAudit)
+	jira.SortKeys(check.Unexpected)
+	return check
+}
+
+// isArchiveRecord reports whether an audit record describes an issue archive
+func isArchiveRecord(record jira.AuditRecord) bool {
+	summary := strings.ToLower(record.Summary)
+	return strings.Contains(summary, "archived") && !strings.Contains(summary, "unarchived")
+}
+
+// PrintAuditCrossCheck writes the result of the audit cross-check to w
+func PrintAuditCrossCheck(w io.Writer, check *AuditCrossCheck) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "Audit Cross-Check")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintf(w, "Confirmed by audit log: %d\n", check.Confirmed)
+	for _, key := range check.MissingAudit {
+		fmt.Fprintf(w, "Missing audit record: %s\n", key)
+	}
+	for _, key := range check.Unexpected {
+		fmt.Fprintf(w, "Audited but not reported as archived: %s\n", key)
+	}
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// CommentData is the template context for the pre-archive comment
+type CommentData struct {
+	IssueKey   string
+	Summary    string
+	Project    string
+	Date       string
+	Label      string
+	Selector   string
+	PolicyHash string
+}
+
+// commenter posts a templated comment on each issue before it is archived
+type commenter struct {
+	template *template.Template
+	base     CommentData
+	interval time.Duration
+	last     time.Time
+}
+
+// SetComment adds a comment rendered from tmpl to every issue right before
+// it is archived, posting at most perSecond comments per second
+func (a *Archiver) SetComment(tmpl *template.Template, base CommentData, perSecond float64) {
+	c := &commenter{template: tmpl, base: base}
+	if perSecond > 0 {
+		c.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	a.commenter = c
+}
+
+// commentBatch comments on every issue of a batch. Comment failures are
+// logged and do not prevent archiving.
+func (a *Archiver) commentBatch(batch []jira.Issue) {
+	c := a.commenter
+	for _, issue := range batch {
+		if wait := c.interval - time.Since(c.last); wait > 0 {
+			time.Sleep(wait)
+		}
+		c.last = time.Now()
+
+		data := c.base
+		data.IssueKey = issue.Key
+		data.Summary = issue.Fields.Summary
+		data.Project = projectKeyOf(issue)
+		data.Date = time.Now().Format("2006-01-02")
+
+		var text strings.Builder
+		if err := c.template.Execute(&text, data); err != nil {
+			a.logger.Warnf("Failed to render comment for %s: %v\n", issue.Key, err)
+			continue
+		}
+		if err := a.client.AddComment(issue.Key, text.String()); err != nil {
+			a.logger.Warnf("Failed to comment on %s: %v\n", issue.Key, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// SortedEscalations returns the keys of chronically failing issues, most
+// consecutive failures first
+func SortedEscalations(chronic map[string]int) []string {
+	keys := make([]string, 0, len(chronic))
+	for key := range chronic {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if chronic[keys[i]] != chronic[keys[j]] {
+			return chronic[keys[i]] > chronic[keys[j]]
+		}
+		return jira.CompareKeys(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// PrintEscalations writes the issues that keep failing across runs to w
+func PrintEscalations(w io.Writer, escalations []Escalation) {
+	if len(escalations) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "Escalation: Repeatedly Failing Issues")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	for _, e := range escalations {
+		fmt.Fprintf(w, "%s - failed in %d consecutive runs\n", e.IssueKey, e.Runs)
+	}
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
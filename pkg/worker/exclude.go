@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Exclusions are the labels, statuses and issue types of issues that are
+// never archived, matched case-insensitively
+type Exclusions struct {
+	Labels     []string
+	Statuses   []string
+	IssueTypes []string
+}
+
+// empty reports whether nothing is excluded
+func (e Exclusions) empty() bool {
+	return len(e.Labels) == 0 && len(e.Statuses) == 0 && len(e.IssueTypes) == 0
+}
+
+// SetExclusions skips the excluded issues before each batch. Label and JQL
+// searches already leave them out; this also covers issues from saved
+// filters, boards, files and approved previews, and guards against a
+// search that did not apply the exclusions.
+func (a *Archiver) SetExclusions(exclusions Exclusions) {
+	a.exclusions = exclusions
+}
+
+// ExclusionReason is the check of SetExclusions for one issue. It returns
+// why the issue is excluded, or "" if it is not.
+func ExclusionReason(issue jira.Issue, exclusions Exclusions) string {
+	for _, label := range issue.Fields.Labels {
+		if containsFold(exclusions.Labels, label) {
+			return fmt.Sprintf("label %q is in EXCLUDE_LABELS", label)
+		}
+	}
+	if status := issue.Fields.Status; status != nil && containsFold(exclusions.Statuses, status.Name) {
+		return fmt.Sprintf("status %q is in EXCLUDE_STATUSES", status.Name)
+	}
+	if issueType := issue.Fields.IssueType; issueType != nil && containsFold(exclusions.IssueTypes, issueType.Name) {
+		return fmt.Sprintf("issue type %q is in EXCLUDE_ISSUE_TYPES", issueType.Name)
+	}
+	return ""
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
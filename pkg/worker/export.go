@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/export"
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// SetExport writes the full JSON of every issue to w before it is archived,
+// downloading up to concurrency issues at once (nil disables)
+func (a *Archiver) SetExport(w export.Writer, concurrency int) {
+	a.exporter = w
+	a.exportConcurrency = concurrency
+}
+
+// exportBatch exports every issue of batch and returns those that were
+// exported. An issue that could not be exported is not archived, so that no
+// issue is archived without its backup; it fails with a retryable error. It
+// returns jira.ErrAPIBudgetExhausted if the budget ran out before every
+// issue was exported.
+func (a *Archiver) exportBatch(batch []jira.Issue) ([]jira.Issue, []ArchiveResult, error) {
+	a.logger.Infof("Exporting %d issues to %s\n", len(batch), a.exporter.Path())
+
+	errs := make([]error, len(batch))
+	sem := make(chan struct{}, max(a.exportConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, issue := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data, err := a.client.ExportIssue(a.issueRef(issue))
+			if err == nil {
+				err = a.exporter.Write(issue.Key, data)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+
+	var exported []jira.Issue
+	var failed []ArchiveResult
+	for i, issue := range batch {
+		err := errs[i]
+		if errors.Is(err, jira.ErrAPIBudgetExhausted) {
+			return nil, nil, err
+		}
+		if err != nil {
+			a.logger.Warnf("Failed to export %s, not archiving it: %v\n", issue.Key, err)
+			failed = append(failed, ArchiveResult{
+				IssueKey: issue.Key,
+				Summary:  issue.Fields.Summary,
+				Error:    fmt.Errorf("export failed: %w", err),
+			})
+			continue
+		}
+		exported = append(exported, issue)
+	}
+	return exported, failed, nil
+}
@@ -0,0 +1,38 @@
+package worker
+
+// SetFailureLabels relabels issues the archive API rejected for a permanent
+// reason: addLabel is added and removeLabel (typically the trigger label)
+// removed, so they surface to humans and are not selected again. Issues a
+// run skipped without sending them are never relabeled. Empty values
+// disable either side.
+func (a *Archiver) SetFailureLabels(addLabel, removeLabel string) {
+	a.failureLabel = addLabel
+	a.triggerLabel = removeLabel
+}
+
+// labelPermanentFailures applies the failure labels to permanently rejected
+// issues. Skipped results were never sent, whatever their reason.
+func (a *Archiver) labelPermanentFailures(results []ArchiveResult) {
+	if a.failureLabel == "" && a.triggerLabel == "" {
+		return
+	}
+
+	var add, remove []string
+	if a.failureLabel != "" {
+		add = []string{a.failureLabel}
+	}
+	if a.triggerLabel != "" {
+		remove = []string{a.triggerLabel}
+	}
+
+	for _, result := range results {
+		if result.Success || result.Skipped || !result.Permanent {
+			continue
+		}
+		if err := a.client.UpdateLabels(result.IssueKey, add, remove); err != nil {
+			a.logger.Warnf("Failed to relabel %s: %v\n", result.IssueKey, err)
+			continue
+		}
+		a.logger.Infof("Relabeled permanently failed issue %s\n", result.IssueKey)
+	}
+}
@@ -0,0 +1,176 @@
+package worker_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// rejectingServer archives every issue except those in rejected, which
+// fail with the archive API's error category, and records label edits
+type rejectingServer struct {
+	*httptest.Server
+
+	rejected map[string]string
+
+	mu      sync.Mutex
+	edited  []string
+	archive [][]string
+}
+
+func newRejectingServer(t *testing.T, rejected map[string]string) *rejectingServer {
+	t.Helper()
+	s := &rejectingServer{rejected: rejected}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /rest/api/3/issue/archive", func(w http.ResponseWriter, r *http.Request) {
+		var req jira.ArchiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.archive = append(s.archive, req.IssueIdsOrKeys)
+		s.mu.Unlock()
+
+		resp := jira.ArchiveResponse{Errors: map[string]jira.ArchiveError{}}
+		for _, key := range req.IssueIdsOrKeys {
+			category, ok := s.rejected[key]
+			if !ok {
+				resp.NumberOfIssuesUpdated++
+				continue
+			}
+			e := resp.Errors[category]
+			e.Count++
+			e.IssueIdsOrKeys = append(e.IssueIdsOrKeys, key)
+			e.Message = category
+			resp.Errors[category] = e
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("PUT /rest/api/3/issue/{key}", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.edited = append(s.edited, r.PathValue("key"))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Edited returns the keys of the issues whose labels were changed
+func (s *rejectingServer) Edited() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Sorted(slices.Values(s.edited))
+}
+
+func newTestArchiver(url string) *worker.Archiver {
+	archiver := worker.NewArchiver(jira.NewClient(url, "user@example.com", "token"), 0)
+	archiver.SetLogger(discardLogger())
+	return archiver
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func testIssue(key string, labels ...string) jira.Issue {
+	return jira.Issue{
+		Key: key,
+		Fields: jira.IssueFields{
+			Summary:   "Issue " + key,
+			Project:   &jira.Project{Key: "PROJ"},
+			IssueType: &jira.IssueType{Name: "Task"},
+			Status:    &jira.Status{Name: "Done"},
+			Labels:    append([]string{"archive"}, labels...),
+		},
+	}
+}
+
+func TestFailureLabelsOnlyForPermanentRejections(t *testing.T) {
+	server := newRejectingServer(t, map[string]string{
+		"PROJ-2": jira.ArchiveErrorIssueIsSubtask,
+		"PROJ-3": jira.ArchiveErrorIssuesNotFound,
+	})
+	archiver := newTestArchiver(server.URL)
+	archiver.SetFailureLabels("archive-failed", "archive")
+	archiver.SetExclusions(worker.Exclusions{Labels: []string{"legal-hold"}})
+
+	issues := []jira.Issue{
+		testIssue("PROJ-1"),
+		testIssue("PROJ-2"),
+		testIssue("PROJ-3"),
+		testIssue("PROJ-4", "legal-hold"),
+	}
+	results, err := archiver.ArchiveIssues(issues)
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+
+	byKey := make(map[string]worker.ArchiveResult)
+	for _, r := range results {
+		byKey[r.IssueKey] = r
+	}
+	if r := byKey["PROJ-1"]; !r.Success {
+		t.Errorf("PROJ-1 = %+v, want archived", r)
+	}
+	if r := byKey["PROJ-2"]; r.Success || r.Skipped || !r.Permanent {
+		t.Errorf("PROJ-2 = %+v, want a permanent failure", r)
+	}
+	if r := byKey["PROJ-3"]; r.Success || r.Permanent {
+		t.Errorf("PROJ-3 = %+v, want a failure that is not permanent", r)
+	}
+	if r := byKey["PROJ-4"]; !r.Skipped || r.Permanent {
+		t.Errorf("PROJ-4 = %+v, want skipped and not permanent", r)
+	}
+
+	if got, want := server.Edited(), []string{"PROJ-2"}; !slices.Equal(got, want) {
+		t.Errorf("relabeled %v, want %v", got, want)
+	}
+}
+
+func TestPolicySkipsAreNotRelabeled(t *testing.T) {
+	server := newRejectingServer(t, nil)
+	archiver := newTestArchiver(server.URL)
+	archiver.SetFailureLabels("archive-failed", "archive")
+	archiver.SetExclusions(worker.Exclusions{Statuses: []string{"In Review"}, IssueTypes: []string{"Epic"}})
+	archiver.SetSecurityLevels(true, nil)
+	archiver.SetServiceDeskPolicy(worker.ServiceDeskResolved)
+
+	excludedStatus := testIssue("PROJ-1")
+	excludedStatus.Fields.Status = &jira.Status{Name: "In Review"}
+	excludedType := testIssue("PROJ-2")
+	excludedType.Fields.IssueType = &jira.IssueType{Name: "Epic"}
+	secured := testIssue("PROJ-3")
+	secured.Fields.Security = &jira.SecurityLevel{Name: "Internal"}
+	request := testIssue("PROJ-4")
+	request.Fields.IssueType = &jira.IssueType{Name: "Service Request"}
+	request.Fields.Project = &jira.Project{Key: "PROJ", ProjectTypeKey: jira.ProjectTypeServiceDesk}
+	request.Fields.Status = &jira.Status{Name: "Waiting for customer"}
+
+	results, err := archiver.ArchiveIssues([]jira.Issue{excludedStatus, excludedType, secured, request})
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	for _, r := range results {
+		if !r.Skipped || r.Permanent {
+			t.Errorf("%s = %+v, want skipped and not permanent", r.IssueKey, r)
+		}
+	}
+	if edited := server.Edited(); len(edited) > 0 {
+		t.Errorf("relabeled %v, want no label changes", edited)
+	}
+	if len(server.archive) > 0 {
+		t.Errorf("sent archive requests %v, want none", server.archive)
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import "github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+
+// retryMovedByID retries the issues the archive API could not find by key,
+// this time by issue ID. An issue moved to another project or renamed
+// between the search and the archive call has a new key but keeps its ID.
+// issueErrors is updated in place with the outcome of the retry. It is only
+// needed when archive requests identify issues by key.
+func (a *Archiver) retryMovedByID(batch []jira.Issue, issueErrors map[string]jira.IssueError) {
+	keysByID := make(map[string]string)
+	var ids []string
+	for _, issue := range batch {
+		issueErr, rejected := issueErrors[issue.Key]
+		if !rejected || issueErr.Category != jira.ArchiveErrorIssuesNotFound || issue.ID == "" {
+			continue
+		}
+		keysByID[issue.ID] = issue.Key
+		ids = append(ids, issue.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	a.logger.Infof("Retrying %d issues not found by key using their IDs\n", len(ids))
+	resp, err := a.client.ArchiveIssues(ids)
+	if err != nil {
+		a.logger.Warnf("Failed to retry issues by ID: %v\n", err)
+		return
+	}
+
+	retryErrors := resp.IssueErrors()
+	var archived []string
+	for _, issue := range batch {
+		key, retried := keysByID[issue.ID]
+		if !retried {
+			continue
+		}
+		if issueErr, rejected := retryErrors[issue.ID]; rejected {
+			issueErrors[key] = issueErr
+			continue
+		}
+		delete(issueErrors, key)
+		archived = append(archived, key)
+		a.logger.Infof("Archived %s by ID %s; it was moved or renamed since the search\n", key, issue.ID)
+	}
+	if len(archived) > 0 {
+		a.warnings.Add(WarningRetriedByID, archived, "%d issues were archived by ID because their key changed after the search", len(archived))
+	}
+}
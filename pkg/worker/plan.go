@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// PlannedBatch is a batch a run would send to the bulk archive API
+type PlannedBatch struct {
+	Canary bool           `json:"canary,omitempty"`
+	Issues []PlannedIssue `json:"issues"`
+}
+
+// PlannedIssue is an issue in a planned batch. Skipped holds the reason the
+// eligibility preflight or the security level check would leave it out.
+type PlannedIssue struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Plan returns the batches ArchiveIssues would send for issues, in order,
+// without archiving, commenting, labeling or tagging anything. With the
+// preflight enabled it reads permissions to mark the issues it would skip.
+func (a *Archiver) Plan(issues []jira.Issue) []PlannedBatch {
+	batches, hasCanary := a.batches(issues)
+	planned := make([]PlannedBatch, len(batches))
+	for i, batch := range batches {
+		planned[i].Canary = hasCanary && i == 0
+		for _, issue := range batch {
+			p := PlannedIssue{Key: issue.Key, Summary: issue.Fields.Summary}
+			if a.checksEligibility() {
+				p.Skipped, _ = a.ineligibleReason(issue)
+			}
+			planned[i].Issues = append(planned[i].Issues, p)
+		}
+	}
+	return planned
+}
+
+// project returns the project shared by every issue in the batch, or ""
+// if the batch mixes projects
+func (b PlannedBatch) project() string {
+	project := ""
+	for i, issue := range b.Issues {
+		p := jira.KeyProject(issue.Key)
+		if i > 0 && p != project {
+			return ""
+		}
+		project = p
+	}
+	return project
+}
+
+// PrintPlan writes the batches a dry run would send to w
+func PrintPlan(w io.Writer, batches []PlannedBatch) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "Dry Run: Archive Preview")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	total, skipped := 0, 0
+	for i, batch := range batches {
+		kind := ""
+		if batch.Canary {
+			kind = "canary, "
+		}
+		if project := batch.project(); project != "" {
+			kind += project + ", "
+		}
+		fmt.Fprintf(w, "\nBatch %d/%d (%s%d issues)\n", i+1, len(batches), kind, len(batch.Issues))
+		for _, issue := range batch.Issues {
+			total++
+			if issue.Skipped != "" {
+				skipped++
+				fmt.Fprintf(w, "  %s  %s  [skip: %s]\n", issue.Key, issue.Summary, issue.Skipped)
+			} else {
+				fmt.Fprintf(w, "  %s  %s\n", issue.Key, issue.Summary)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nWould archive: %d issues in %d batches\n", total-skipped, len(batches))
+	if skipped > 0 {
+		fmt.Fprintf(w, "Would skip: %d\n", skipped)
+	}
+	fmt.Fprintln(w, "No issues were archived.")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
+
+// WritePlanCSV writes one row per planned issue, in batch order
+func WritePlanCSV(w io.Writer, batches []PlannedBatch) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"batch", "canary", "issue_key", "summary", "skipped"})
+	for i, batch := range batches {
+		for _, issue := range batch.Issues {
+			cw.Write([]string{strconv.Itoa(i + 1), strconv.FormatBool(batch.Canary), issue.Key, issue.Summary, issue.Skipped})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
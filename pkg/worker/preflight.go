@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// SetPreflight enables the eligibility pre-check before each batch
+func (a *Archiver) SetPreflight(enabled bool) {
+	a.preflight = enabled
+}
+
+// checkEligibility filters out issues the archive API is known to reject,
+// returning the issues to send and skipped results for the rest
+func (a *Archiver) checkEligibility(batch []jira.Issue) ([]jira.Issue, []ArchiveResult) {
+	var eligible []jira.Issue
+	var skipped []ArchiveResult
+
+	for _, issue := range batch {
+		reason, permanent := a.ineligibleReason(issue)
+		if reason == "" {
+			eligible = append(eligible, issue)
+			continue
+		}
+
+		a.logger.Infof("Skipping %s: %s\n", issue.Key, reason)
+		skipped = append(skipped, ArchiveResult{
+			IssueKey:  issue.Key,
+			Summary:   issue.Fields.Summary,
+			Skipped:   true,
+			Permanent: permanent,
+			Error:     fmt.Errorf("%s", reason),
+		})
+	}
+
+	return eligible, skipped
+}
+
+// ineligibleReason returns why an issue cannot or must not be archived, or
+// "" if it can. permanent is set for issues the archive API would reject;
+// issues left out by policy, such as EXCLUDE_*, their security level or a
+// service desk request that is not resolved yet, may be archived by a
+// later run.
+func (a *Archiver) ineligibleReason(issue jira.Issue) (reason string, permanent bool) {
+	if reason := ExclusionReason(issue, a.exclusions); reason != "" {
+		return reason, false
+	}
+	if reason := a.securityReason(issue); reason != "" {
+		return reason, false
+	}
+	if reason := ServiceDeskReason(issue, a.serviceDeskPolicy); reason != "" {
+		return reason, false
+	}
+	if !a.preflight {
+		return "", false
+	}
+
+	if issue.Fields.IssueType != nil && issue.Fields.IssueType.Subtask {
+		return "subtasks cannot be archived on their own; archive the parent instead", true
+	}
+
+	if issue.Fields.Project != nil {
+		if !a.canArchiveInProject(issue.Fields.Project.Key) {
+			return fmt.Sprintf("no permission to archive issues in project %s", issue.Fields.Project.Key), true
+		}
+	}
+
+	return "", false
+}
+
+// canArchiveInProject checks (and caches) whether the user may archive in a
+// project. When the check itself fails the archive API decides instead: the
+// failure is logged once and cached for the rest of the run.
+func (a *Archiver) canArchiveInProject(projectKey string) bool {
+	if allowed, ok := a.projectPermissions[projectKey]; ok {
+		return allowed
+	}
+
+	granted, err := a.client.GetMyPermissions(projectKey, jira.PermissionAdminister, jira.PermissionAdministerProjects)
+	if err != nil {
+		a.logger.Warnf("Permission check for project %s failed, not checking it again: %v\n", projectKey, err)
+		a.projectPermissions[projectKey] = true
+		return true
+	}
+
+	allowed := granted[jira.PermissionAdminister] || granted[jira.PermissionAdministerProjects]
+	a.projectPermissions[projectKey] = allowed
+	return allowed
+}
@@ -0,0 +1,57 @@
+package worker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+func TestFailedPermissionCheckIsCachedPerProject(t *testing.T) {
+	var checks atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/3/mypermissions", func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("PUT /rest/api/3/issue/archive", func(w http.ResponseWriter, r *http.Request) {
+		var req jira.ArchiveRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jira.ArchiveResponse{NumberOfIssuesUpdated: len(req.IssueIdsOrKeys)})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := jira.NewClient(server.URL, "user@example.com", "token")
+	client.SetMaxRetries(0)
+	archiver := worker.NewArchiver(client, 0)
+	var logs bytes.Buffer
+	archiver.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	// One issue per batch, so that every batch runs the pre-check
+	archiver.SetBatchSize(1)
+	archiver.SetPreflight(true)
+
+	results, err := archiver.ArchiveIssues([]jira.Issue{testIssue("PROJ-1"), testIssue("PROJ-2"), testIssue("PROJ-3")})
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s = %+v, want archived once the archive API accepts it", r.IssueKey, r)
+		}
+	}
+	if n := checks.Load(); n != 1 {
+		t.Errorf("checked permissions %d times, want once for the project", n)
+	}
+	if n := strings.Count(logs.String(), "Permission check for project PROJ failed"); n != 1 {
+		t.Errorf("logged %d permission warnings, want 1:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress event types
+const (
+	EventSearchCompleted = "search_completed"
+	EventRunStarted      = "run_started"
+	EventBatchStarted    = "batch_started"
+	EventIssueArchived   = "issue_archived"
+	EventIssueFailed     = "issue_failed"
+	EventIssueSkipped    = "issue_skipped"
+	EventBatchFinished   = "batch_finished"
+	EventRunFinished     = "run_finished"
+)
+
+// ProgressEvent is a single machine-readable progress record
+type ProgressEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Batch     int       `json:"batch,omitempty"`
+	Batches   int       `json:"batches,omitempty"`
+	IssueKey  string    `json:"issueKey,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Total     int       `json:"total"`
+	Processed int       `json:"processed"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+}
+
+// Progress writes progress events as newline-delimited JSON
+type Progress struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewProgress creates a Progress writing NDJSON to w
+func NewProgress(w io.Writer) *Progress {
+	return &Progress{enc: json.NewEncoder(w)}
+}
+
+// Emit writes an event. It is safe to call on a nil Progress.
+func (p *Progress) Emit(event ProgressEvent) {
+	if p == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Progress is best-effort and must never break a run
+	_ = p.enc.Encode(event)
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// ProjectSummary counts the results of one project in a run
+type ProjectSummary struct {
+	Project   string `json:"project"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+}
+
+// SummarizeProjects counts the results per project, ordered by project
+// key. It returns nil when all results belong to one project.
+func SummarizeProjects(results []ArchiveResult) []ProjectSummary {
+	byProject := make(map[string]*ProjectSummary)
+	for _, result := range results {
+		project := jira.KeyProject(result.IssueKey)
+		s, ok := byProject[project]
+		if !ok {
+			s = &ProjectSummary{Project: project}
+			byProject[project] = s
+		}
+		s.Total++
+		if result.Success {
+			s.Succeeded++
+		} else if result.Skipped {
+			s.Skipped++
+		} else {
+			s.Failed++
+		}
+	}
+	if len(byProject) < 2 {
+		return nil
+	}
+
+	summaries := make([]ProjectSummary, 0, len(byProject))
+	for _, s := range byProject {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Project < summaries[j].Project })
+	return summaries
+}
+
+// PrintProjectSummary writes the per-project counts of a multi-project run
+// to w
+func PrintProjectSummary(w io.Writer, projects []ProjectSummary) {
+	if len(projects) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "Projects")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintf(w, "%-12s %8s %10s %8s %8s\n", "Project", "Total", "Succeeded", "Failed", "Skipped")
+	for _, p := range projects {
+		fmt.Fprintf(w, "%-12s %8d %10d %8d %8d\n", p.Project, p.Total, p.Succeeded, p.Failed, p.Skipped)
+	}
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
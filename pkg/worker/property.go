@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// RunProperty is the entity property value recording which run archived an issue
+type RunProperty struct {
+	RunID      string `json:"runId"`
+	PolicyHash string `json:"policyHash,omitempty"`
+	ArchivedAt string `json:"archivedAt"`
+}
+
+// SetRunProperty stores value in the issue property key on every issue
+// right before it is archived. An empty key disables tagging.
+func (a *Archiver) SetRunProperty(key string, value RunProperty) {
+	a.propertyKey = key
+	a.property = value
+}
+
+// tagBatch sets the run property on every issue of a batch. Failures are
+// logged and do not prevent the issue from being archived.
+func (a *Archiver) tagBatch(batch []jira.Issue) {
+	value := a.property
+	value.ArchivedAt = time.Now().UTC().Format(time.RFC3339)
+	for _, issue := range batch {
+		if err := a.client.SetIssueProperty(issue.Key, a.propertyKey, value); err != nil {
+			a.logger.Warnf("Failed to set property %s on %s: %v\n", a.propertyKey, issue.Key, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Escalation is an issue that failed in several consecutive runs
+type Escalation struct {
+	IssueKey string `json:"issueKey"`
+	Runs     int    `json:"runs"`
+}
+
+// RunResult is the structured outcome of a run. The runner returns it, and
+// the summary and report templates, the JSON report, the run history and
+// alerts are all built from it. The embedded Summary keeps .Total, .Results
+// and the other counts available at the top level of a template.
+type RunResult struct {
+	Summary
+
+	RunID      string    `json:"runId"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+
+	// Selection criteria
+	ProjectKey string `json:"projectKey"`
+	Label      string `json:"label"`
+	Selector   string `json:"selector"`
+	PolicyHash string `json:"policyHash"`
+
+	// Found is the number of issues selected, before any filter such as
+	// sampling; Total counts the issues processed
+	Found int `json:"found"`
+	// Frozen describes the freeze window that prevented the run, if any
+	Frozen string `json:"frozen,omitempty"`
+	// Plan lists the batches a dry run would have sent; nothing was archived
+	DryRun bool           `json:"dryRun,omitempty"`
+	Plan   []PlannedBatch `json:"plan,omitempty"`
+
+	// Projects breaks the counts down per project when the run spanned
+	// several projects
+	Projects []ProjectSummary `json:"projects,omitempty"`
+	// ServiceDesk splits the counts into service desk requests and other
+	// issues when the run processed service desk requests
+	ServiceDesk []IssueKindSummary `json:"serviceDesk,omitempty"`
+
+	Timings     Timings           `json:"timings"`
+	Requests    jira.RequestStats `json:"requests"`
+	Escalations []Escalation      `json:"escalations,omitempty"`
+	Warnings    []Warning         `json:"warnings,omitempty"`
+	// Audit is the audit log cross-check, if enabled and available
+	Audit *AuditCrossCheck `json:"audit,omitempty"`
+
+	// Error explains why the run stopped early; empty if it completed
+	Error string `json:"error,omitempty"`
+	// Remaining lists, in key order, the issues a run that stopped early
+	// did not get to
+	Remaining []string `json:"remaining,omitempty"`
+}
+
+// RunReport is the former name of RunResult.
+//
+// Deprecated: use RunResult.
+type RunReport = RunResult
+
+// Timings breaks down where a run spent its time
+type Timings struct {
+	Search  time.Duration `json:"searchNanos"`
+	Archive time.Duration `json:"archiveNanos"`
+}
+
+// Archived reports whether the run got as far as archiving. It did not if
+// a freeze window was active or no issues were left to archive.
+func (r *RunResult) Archived() bool {
+	return r.RunID != ""
+}
+
+// Stopped reports whether archiving stopped before every issue was processed
+func (r *RunResult) Stopped() bool {
+	return r.Error != ""
+}
+
+// EscalationKeys returns the keys of the escalated issues, most
+// consecutive failures first
+func (r *RunResult) EscalationKeys() []string {
+	keys := make([]string, len(r.Escalations))
+	for i, e := range r.Escalations {
+		keys[i] = e.IssueKey
+	}
+	return keys
+}
+
+// WarningMessages returns the messages of the warnings with code
+func (r *RunResult) WarningMessages(code string) []string {
+	var messages []string
+	for _, w := range r.Warnings {
+		if w.Code == code {
+			messages = append(messages, w.Message)
+		}
+	}
+	return messages
+}
+
+// Escalations converts chronic failures into escalations, most consecutive
+// failures first
+func Escalations(chronic map[string]int) []Escalation {
+	var escalations []Escalation
+	for _, key := range SortedEscalations(chronic) {
+		escalations = append(escalations, Escalation{IssueKey: key, Runs: chronic[key]})
+	}
+	return escalations
+}
+
+// resultsCSVHeader is the header row of WriteResultsCSV
+var resultsCSVHeader = []string{
+	"run_id", "started_at", "finished_at", "issue_key", "summary", "batch",
+	"success", "skipped", "permanent", "error", "processed_at",
+}
+
+// WriteResultsCSV writes one row per processed issue, in result order,
+// for spreadsheets and compliance dashboards
+func WriteResultsCSV(w io.Writer, result *RunResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write(resultsCSVHeader)
+	for _, r := range result.Results {
+		errMsg, processedAt := "", ""
+		if r.Error != nil {
+			errMsg = r.Error.Error()
+		}
+		if !r.ProcessedAt.IsZero() {
+			processedAt = r.ProcessedAt.Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			result.RunID,
+			result.StartedAt.Format(time.RFC3339),
+			result.FinishedAt.Format(time.RFC3339),
+			r.IssueKey,
+			r.Summary,
+			strconv.Itoa(r.Batch),
+			strconv.FormatBool(r.Success),
+			strconv.FormatBool(r.Skipped),
+			strconv.FormatBool(r.Permanent),
+			errMsg,
+			processedAt,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
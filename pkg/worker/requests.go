@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// PrintRequestStats writes to w how many requests the run sent, how much of its
+// time went to Jira throttling and the latency of each endpoint
+func PrintRequestStats(w io.Writer, stats jira.RequestStats) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "API Requests")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintf(w, "Requests sent: %d\n", stats.Requests)
+	fmt.Fprintf(w, "Retried: %d\n", stats.Retries)
+	fmt.Fprintf(w, "Rate limited (429): %d\n", stats.RateLimited)
+	fmt.Fprintf(w, "Total backoff: %s\n", stats.Backoff.Round(time.Millisecond))
+	if stats.RateLimitWait > 0 {
+		fmt.Fprintf(w, "Rate limiter wait: %s\n", stats.RateLimitWait.Round(time.Millisecond))
+	}
+	if stats.Hedged > 0 {
+		fmt.Fprintf(w, "Hedged searches: %d (%d answered first by the hedge)\n", stats.Hedged, stats.HedgeWins)
+	}
+	if stats.NotModified > 0 {
+		fmt.Fprintf(w, "Metadata not modified (304): %d\n", stats.NotModified)
+	}
+	if len(stats.Endpoints) > 0 {
+		fmt.Fprintf(w, "\n%-36s %6s %8s %8s %8s %8s\n", "Endpoint", "Calls", "p50", "p90", "p99", "max")
+		for _, e := range stats.Endpoints {
+			fmt.Fprintf(w, "%-36s %6d %8s %8s %8s %8s\n", e.Endpoint, e.Requests,
+				e.P50.Round(time.Millisecond), e.P90.Round(time.Millisecond), e.P99.Round(time.Millisecond), e.Max.Round(time.Millisecond))
+		}
+	}
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
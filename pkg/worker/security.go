@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// SetSecurityLevels skips issues with an issue security level before each
+// batch: every secured issue when skipSecured is set, otherwise those
+// whose level is not in allowed (names or IDs). Issues without a level are
+// not affected, and an empty allowed list with skipSecured unset disables
+// the check.
+func (a *Archiver) SetSecurityLevels(skipSecured bool, allowed []string) {
+	a.skipSecured = skipSecured
+	a.securityLevels = allowed
+}
+
+// checksEligibility reports whether batches are checked before they are sent
+func (a *Archiver) checksEligibility() bool {
+	return a.preflight || a.skipSecured || len(a.securityLevels) > 0 || !a.exclusions.empty() ||
+		(a.serviceDeskPolicy != "" && a.serviceDeskPolicy != ServiceDeskArchive)
+}
+
+// securityReason returns why the issue's security level keeps it from
+// being archived, or "" if it does not
+func (a *Archiver) securityReason(issue jira.Issue) string {
+	return SecurityReason(issue, a.skipSecured, a.securityLevels)
+}
+
+// SecurityReason is the security level check of SetSecurityLevels for one
+// issue. It returns why the issue is skipped, or "" if it is not.
+func SecurityReason(issue jira.Issue, skipSecured bool, allowed []string) string {
+	level := issue.Fields.Security
+	if level == nil {
+		return ""
+	}
+	if skipSecured {
+		return fmt.Sprintf("issue security level %q is set", level.Name)
+	}
+	if len(allowed) == 0 {
+		return ""
+	}
+	listed := slices.ContainsFunc(allowed, func(l string) bool {
+		return l == level.ID || strings.EqualFold(l, level.Name)
+	})
+	if !listed {
+		return fmt.Sprintf("issue security level %q is not in ARCHIVE_SECURITY_LEVELS", level.Name)
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// Service desk policies selected by SERVICE_DESK_POLICY
+const (
+	// Archive service desk requests like any other issue
+	ServiceDeskArchive = "archive"
+	// Skip every service desk request
+	ServiceDeskSkip = "skip"
+	// Skip service desk requests that are not resolved
+	ServiceDeskResolved = "resolved"
+)
+
+// Issue kinds reported by SummarizeServiceDesk
+const (
+	KindServiceDesk = "service desk requests"
+	KindOther       = "other issues"
+)
+
+// IsServiceDeskRequest reports whether the issue belongs to a Jira Service
+// Management project, which makes it a customer request
+func IsServiceDeskRequest(issue jira.Issue) bool {
+	return issue.Fields.Project != nil && issue.Fields.Project.ProjectTypeKey == jira.ProjectTypeServiceDesk
+}
+
+// isResolved reports whether the issue's status is in the done category
+func isResolved(issue jira.Issue) bool {
+	status := issue.Fields.Status
+	return status != nil && status.StatusCategory != nil && status.StatusCategory.Key == "done"
+}
+
+// SetServiceDeskPolicy decides before each batch what happens to service
+// desk requests: ServiceDeskSkip skips all of them, ServiceDeskResolved the
+// unresolved ones. Other issues are not affected.
+func (a *Archiver) SetServiceDeskPolicy(policy string) {
+	a.serviceDeskPolicy = policy
+}
+
+// ServiceDeskReason is the service desk check of SetServiceDeskPolicy for
+// one issue. It returns why the issue is skipped, or "" if it is not.
+func ServiceDeskReason(issue jira.Issue, policy string) string {
+	if !IsServiceDeskRequest(issue) {
+		return ""
+	}
+	switch policy {
+	case ServiceDeskSkip:
+		return "service desk requests are not archived (SERVICE_DESK_POLICY=skip)"
+	case ServiceDeskResolved:
+		if !isResolved(issue) {
+			status := "unknown"
+			if issue.Fields.Status != nil {
+				status = issue.Fields.Status.Name
+			}
+			return fmt.Sprintf("service desk request is not resolved (status %q)", status)
+		}
+	}
+	return ""
+}
+
+// IssueKindSummary counts the results of one kind of issue in a run
+type IssueKindSummary struct {
+	Kind      string `json:"kind"`
+	Total     int    `json:"total"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+}
+
+// SummarizeServiceDesk counts the results of service desk requests and of
+// other issues separately. It returns nil when no service desk request was
+// processed.
+func SummarizeServiceDesk(issues []jira.Issue, results []ArchiveResult) []IssueKindSummary {
+	requests := make(map[string]bool)
+	for _, issue := range issues {
+		if IsServiceDeskRequest(issue) {
+			requests[issue.Key] = true
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	summaries := []IssueKindSummary{{Kind: KindServiceDesk}, {Kind: KindOther}}
+	for _, result := range results {
+		s := &summaries[1]
+		if requests[result.IssueKey] {
+			s = &summaries[0]
+		}
+		s.Total++
+		if result.Success {
+			s.Succeeded++
+		} else if result.Skipped {
+			s.Skipped++
+		} else {
+			s.Failed++
+		}
+	}
+	if summaries[0].Total == 0 {
+		return nil
+	}
+	return summaries
+}
+
+// UnresolvedServiceDeskRequests returns the keys of the service desk
+// requests that were archived while still unresolved
+func UnresolvedServiceDeskRequests(issues []jira.Issue, results []ArchiveResult) []string {
+	archived := make(map[string]bool)
+	for _, result := range results {
+		if result.Success {
+			archived[result.IssueKey] = true
+		}
+	}
+	var keys []string
+	for _, issue := range issues {
+		if archived[issue.Key] && IsServiceDeskRequest(issue) && !isResolved(issue) {
+			keys = append(keys, issue.Key)
+		}
+	}
+	return keys
+}
+
+// PrintServiceDeskSummary writes the service desk and other issue counts
+// of a run that processed service desk requests to w
+func PrintServiceDeskSummary(w io.Writer, kinds []IssueKindSummary) {
+	if len(kinds) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "Service Desk")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintf(w, "%-22s %6s %10s %7s %8s\n", "Kind", "Total", "Succeeded", "Failed", "Skipped")
+	for _, k := range kinds {
+		fmt.Fprintf(w, "%-22s %6d %10d %7d %8d\n", k.Kind, k.Total, k.Succeeded, k.Failed, k.Skipped)
+	}
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// UnarchiveIssues restores archived issues by key using the bulk unarchive
+// API, in batches of the configured size, and returns one result per key in
+// order. Once the API call budget is used up, the remaining issues fail
+// with jira.ErrAPIBudgetExhausted.
+func (a *Archiver) UnarchiveIssues(keys []string) []ArchiveResult {
+	results := make([]ArchiveResult, 0, len(keys))
+	for i := 0; i < len(keys); i += a.batchSize {
+		batch := keys[i:min(i+a.batchSize, len(keys))]
+		a.logger.Infof("Unarchiving batch of %d issues\n", len(batch))
+
+		resp, err := a.client.UnarchiveIssues(batch)
+		if errors.Is(err, jira.ErrAPIBudgetExhausted) {
+			for _, key := range keys[i:] {
+				results = append(results, ArchiveResult{IssueKey: key, Error: err})
+			}
+			a.logger.Warnf("Stopping: API call budget exhausted, %d issues not restored\n", len(keys)-i)
+			return results
+		}
+
+		var issueErrors map[string]jira.IssueError
+		if resp != nil {
+			issueErrors = resp.IssueErrors()
+		}
+		for _, key := range batch {
+			issueErr, rejected := issueErrors[key]
+			switch {
+			case err != nil:
+				results = append(results, ArchiveResult{IssueKey: key, Error: err})
+				a.logger.Warnf("Failed to unarchive %s: %v\n", key, err)
+			case rejected:
+				results = append(results, ArchiveResult{
+					IssueKey:  key,
+					Permanent: issueErr.IsPermanent(),
+					Error:     fmt.Errorf("%s", issueErr.Message),
+				})
+				a.logger.Warnf("Failed to unarchive %s: %s\n", key, issueErr.Message)
+			default:
+				results = append(results, ArchiveResult{IssueKey: key, Success: true})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+)
+
+// verifyChunkSize is the number of issues checked per verification search,
+// keeping the JQL well below Jira's query length limit
+const verifyChunkSize = 100
+
+// SetVerify verifies every batch after archiving, not just the canary.
+// Up to concurrency verification searches run at once.
+func (a *Archiver) SetVerify(all bool, concurrency int) {
+	a.verifyAll = all
+	a.verifyConcurrency = concurrency
+}
+
+// verifyArchived checks that the successfully archived issues of batch are
+// archived and marks any that are not as failed. results[i] is the result
+// for batch[i]. Issues that could not be checked keep their result and are
+// reported in a WarningUnverified warning.
+//
+// Jira excludes archived issues from search, so each chunk of issues is
+// checked with one search for their IDs: any issue still returned was not
+// archived. Chunks are searched concurrently.
+func (a *Archiver) verifyArchived(batch []jira.Issue, results []ArchiveResult) {
+	var pending []int
+	for i, result := range results {
+		if result.Success {
+			pending = append(pending, i)
+		}
+	}
+
+	concurrency := max(a.verifyConcurrency, 1)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var unverified []string
+	for start := 0; start < len(pending); start += verifyChunkSize {
+		chunk := pending[start:min(start+verifyChunkSize, len(pending))]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			keys := a.verifyChunk(batch, results, chunk)
+			mu.Lock()
+			unverified = append(unverified, keys...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(unverified) > 0 {
+		sort.Strings(unverified)
+		a.warnings.Add(WarningUnverified, unverified, "%d archived issues could not be verified", len(unverified))
+	}
+}
+
+// verifyChunk verifies the issues at the given indexes with a single
+// search, re-fetching them one by one if the search fails. It returns the
+// keys of the issues that could not be checked.
+func (a *Archiver) verifyChunk(batch []jira.Issue, results []ArchiveResult, indexes []int) []string {
+	refs := make([]string, len(indexes))
+	for i, idx := range indexes {
+		refs[i] = verifyRef(batch[idx])
+	}
+
+	open, err := a.client.GetAllIssues(fmt.Sprintf("key in (%s)", strings.Join(refs, ", ")))
+	if err != nil {
+		a.logger.Warnf("Verification search failed, checking %d issues individually: %v\n", len(indexes), err)
+		var unverified []string
+		for _, idx := range indexes {
+			if !a.verifyIssue(&results[idx]) {
+				unverified = append(unverified, results[idx].IssueKey)
+			}
+		}
+		return unverified
+	}
+
+	// The search may return other issues; only those of the chunk matter
+	stillOpen := make(map[string]bool, len(open))
+	for _, issue := range open {
+		stillOpen[issue.ID] = true
+		stillOpen[issue.Key] = true
+	}
+	for _, idx := range indexes {
+		issue := batch[idx]
+		if (issue.ID != "" && stillOpen[issue.ID]) || stillOpen[issue.Key] {
+			a.failVerification(&results[idx])
+		}
+	}
+	return nil
+}
+
+// verifyIssue re-fetches a single issue and checks its archive date. It
+// returns false if the issue could not be fetched, leaving the result as
+// Jira reported it: a failed check says nothing about the archive.
+func (a *Archiver) verifyIssue(result *ArchiveResult) bool {
+	issue, err := a.client.GetIssue(result.IssueKey, "archiveddate")
+	if err != nil {
+		a.logger.Warnf("Could not verify %s: %v\n", result.IssueKey, err)
+		return false
+	}
+	if issue.Fields.ArchivedDate == "" {
+		a.failVerification(result)
+	}
+	return true
+}
+
+// failVerification marks an issue reported as archived that is not
+func (a *Archiver) failVerification(result *ArchiveResult) {
+	result.Success = false
+	result.Error = fmt.Errorf("verification failed: issue is not archived")
+	a.logger.Warnf("Verification failed: %s is not archived\n", result.IssueKey)
+}
+
+// verifyRef returns the ID or key identifying issue in verification JQL.
+// IDs survive moves between projects, which change the key.
+func verifyRef(issue jira.Issue) string {
+	if issue.ID != "" {
+		return issue.ID
+	}
+	return issue.Key
+}
@@ -0,0 +1,99 @@
+package worker_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/c_yamada/jira_cloud_bulk_archive/internal/jira"
+	"github.com/c_yamada/jira_cloud_bulk_archive/pkg/worker"
+)
+
+// verifyServer archives every issue it is sent. Verification searches
+// return the issues in open, or fail if searchFails is set, and fetching a
+// single issue always fails.
+func verifyServer(t *testing.T, open []jira.Issue, searchFails bool) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /rest/api/3/issue/archive", func(w http.ResponseWriter, r *http.Request) {
+		var req jira.ArchiveRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jira.ArchiveResponse{NumberOfIssuesUpdated: len(req.IssueIdsOrKeys)})
+	})
+	mux.HandleFunc("GET /rest/api/3/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		if searchFails {
+			http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jira.SearchResult{Issues: open, Total: len(open)})
+	})
+	mux.HandleFunc("GET /rest/api/3/issue/{key}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorMessages":["unavailable"]}`, http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newVerifyingArchiver(url string, warnings *worker.Warnings) *worker.Archiver {
+	client := jira.NewClient(url, "user@example.com", "token")
+	client.SetMaxRetries(0)
+	archiver := worker.NewArchiver(client, 0)
+	archiver.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	archiver.SetWarnings(warnings)
+	archiver.SetVerify(true, 2)
+	return archiver
+}
+
+func TestVerifyFailureKeepsArchivedResult(t *testing.T) {
+	server := verifyServer(t, nil, true)
+	warnings := &worker.Warnings{}
+	archiver := newVerifyingArchiver(server.URL, warnings)
+	archiver.SetCanary(1)
+
+	results, err := archiver.ArchiveIssues([]jira.Issue{testIssue("PROJ-1"), testIssue("PROJ-2")})
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v, want the canary to pass", err)
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s = %+v, want archived", r.IssueKey, r)
+		}
+	}
+
+	var unverified []string
+	for _, w := range warnings.List() {
+		if w.Code == worker.WarningUnverified {
+			unverified = append(unverified, w.IssueKeys...)
+		}
+	}
+	if want := []string{"PROJ-1", "PROJ-2"}; !slices.Equal(unverified, want) {
+		t.Errorf("unverified warnings for %v, want %v", unverified, want)
+	}
+}
+
+func TestVerifyFailsIssuesStillOpen(t *testing.T) {
+	server := verifyServer(t, []jira.Issue{testIssue("PROJ-2")}, false)
+	warnings := &worker.Warnings{}
+	archiver := newVerifyingArchiver(server.URL, warnings)
+
+	results, err := archiver.ArchiveIssues([]jira.Issue{testIssue("PROJ-1"), testIssue("PROJ-2")})
+	if err != nil {
+		t.Fatalf("ArchiveIssues() error = %v", err)
+	}
+	if !results[0].Success {
+		t.Errorf("PROJ-1 = %+v, want archived", results[0])
+	}
+	if results[1].Success || results[1].Error == nil {
+		t.Errorf("PROJ-2 = %+v, want a verification failure", results[1])
+	}
+	if list := warnings.List(); len(list) > 0 {
+		t.Errorf("warnings = %v, want none", list)
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Warning codes
+const (
+	// Issues were filtered out by the eligibility preflight
+	WarningIssuesSkipped = "issues_skipped"
+	// Issues were archived by ID after the archive API did not find their key
+	WarningRetriedByID = "retried_by_id"
+	// The selection policy differs from the previous run's
+	WarningPolicyChanged = "policy_changed"
+	// A metric is significantly worse than in recent runs
+	WarningRegression = "regression"
+	// Service desk requests were archived before they were resolved
+	WarningUnresolvedRequests = "unresolved_requests"
+	// Archived issues could not be verified because the check itself failed
+	WarningUnverified = "unverified"
+)
+
+// Warning is an informational note about a run. Unlike a failure it does
+// not mean anything went wrong, only that the run did not go exactly as
+// configured and an operator may want to know why.
+type Warning struct {
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	IssueKeys []string `json:"issueKeys,omitempty"`
+}
+
+// Warnings collects the warnings raised during a run. It is safe for
+// concurrent use, and a nil *Warnings discards everything added to it.
+type Warnings struct {
+	mu   sync.Mutex
+	list []Warning
+}
+
+// Add records a warning about issueKeys (which may be empty)
+func (w *Warnings) Add(code string, issueKeys []string, format string, args ...any) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, Warning{
+		Code:      code,
+		Message:   fmt.Sprintf(format, args...),
+		IssueKeys: issueKeys,
+	})
+}
+
+// List returns the warnings in the order they were added
+func (w *Warnings) List() []Warning {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Warning(nil), w.list...)
+}